@@ -0,0 +1,627 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+	"github.com/jcawley/kyma-app-connector/pkg/fake"
+	"github.com/jcawley/kyma-app-connector/pkg/mock"
+	"github.com/jcawley/kyma-app-connector/pkg/validate"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"serve":           {"start the http server (default)", serve},
+		"connect":         {"run the whole connection process with a token", withConnections(connect)},
+		"status":          {"print the status of the connections", withConnections(status)},
+		"renew":           {"renew the client certificate", withConnections(renew)},
+		"register-api":    {"register an api spec", withConnections(registerAPI)},
+		"register-events": {"register an event spec", withConnections(registerEvents)},
+		"registrations":   {"list the apis and events registered for the application", withConnections(registrations)},
+		"spec-docs":       {"list the documents of assets/spec-docs that can be registered with --file", specDocs},
+		"sync":            {"create, update and optionally prune registrations to match a spec set", withConnections(syncRegistrations)},
+		"update-api":      {"replace a registered api spec", withConnections(updateAPI)},
+		"update-events":   {"replace a registered event spec", withConnections(updateEvents)},
+		"delete-api":      {"remove a registered api spec", withConnections(deleteAPI)},
+		"delete-events":   {"remove a registered event spec", withConnections(deleteEvents)},
+		"validate":        {"check an api or event spec without sending it", validateSpec},
+		"openapi":         {"print the open api document generated from the mock routes", openAPI},
+		"send-event":      {"send an event", withConnections(sendEvent)},
+		"event-mode":      {"set how the events of a connection are published", withConnections(eventMode)},
+		"disconnect":      {"unregister the services and revoke the certificate", withConnections(disconnect)},
+		"fake-kyma":       {"start a local stand-in for the kyma application connector", fakeKyma},
+		"fake-compass":    {"start a local stand-in for the compass connector and director", fakeCompass},
+	}
+}
+
+//restores the persisted connections before running a command that uses them
+func withConnections(run func(args []string) error) func(args []string) error {
+	return func(args []string) error {
+		connector.Restore()
+		return run(args)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+//adds the flag selecting the connection shared by all connection commands
+func connectionFlag(flags *flag.FlagSet) *string {
+	return flags.String("name", connector.DefaultConnection, "name of the connection")
+}
+
+func printJSON(data interface{}) error {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+//adds the flag sending a spec even if validation found problems
+func forceFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("force", false, "send the spec even if it is invalid")
+}
+
+//reads a file, "-" reads stdin
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func connect(args []string) error {
+	flags := newFlagSet("connect")
+	name := connectionFlag(flags)
+	token := flags.String("token", "", "token url (rest) or base64 token data (graphql), - reads stdin")
+	hostURL := flags.String("host-url", "", "target url registered for the api")
+	keyAlgorithm := flags.String("key-algorithm", "", "key algorithm of the client certificate e.g. rsa2048, rsa4096, ecdsa-p256 or ecdsa-p384, defaults to the one advertised by the connector")
+	subjectAltNames := flags.String("san", "", "comma separated subject alternative names of the client certificate, e.g. DNS:app.example.com,IP:10.0.0.1")
+	force := forceFlag(flags)
+	packageConfig := flags.String("package-config", "", "json or yaml file with the package created for a compass application, overrides "+connector.PackageConfigEnv)
+	packageName := flags.String("package-name", "", "name of the package created for a compass application")
+	eventModeName := eventModeFlag(flags)
+	eventURL := flags.String("event-url", "", "url events are published to instead of the events url of the system")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *token == "" {
+		return errors.New("--token is required")
+	}
+	if err := connector.CheckEventMode(*eventModeName); err != nil {
+		return err
+	}
+
+	tokenData := *token
+	if tokenData == "-" {
+		input, err := readInput("-")
+		if err != nil {
+			return err
+		}
+		tokenData = strings.TrimSpace(string(input))
+	}
+
+	connectReq := connector.ConnectRequest{TokenData: tokenData, HostURL: *hostURL, KeyAlgorithm: *keyAlgorithm, Force: *force, EventMode: *eventModeName, EventURL: *eventURL}
+	if *subjectAltNames != "" {
+		connectReq.SubjectAltNames = strings.Split(*subjectAltNames, ",")
+	}
+
+	if *packageConfig != "" || *packageName != "" {
+		pkg := connector.PackageConfig{}
+		if *packageConfig != "" {
+			var err error
+			if pkg, err = connector.ReadPackageConfig(*packageConfig); err != nil {
+				return err
+			}
+		}
+		if *packageName != "" {
+			pkg.Name = *packageName
+		}
+		connectReq.Package = &pkg
+	}
+
+	report := connector.Connect(*name, connectReq)
+	if err := printJSON(report); err != nil {
+		return err
+	}
+
+	if !report.Success {
+		return errors.New("connection process failed")
+	}
+	return nil
+}
+
+func status(args []string) error {
+	flags := newFlagSet("status")
+	name := flags.String("name", "", "name of the connection, all connections if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		return printJSON(connector.GetConnections())
+	}
+
+	info, err := connector.GetConnection(*name)
+	if err != nil {
+		return err
+	}
+	return printJSON(info)
+}
+
+func renew(args []string) error {
+	flags := newFlagSet("renew")
+	name := connectionFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := connector.RenewConnection(*name); err != nil {
+		return err
+	}
+	fmt.Println("Certificate has been renewed")
+	return nil
+}
+
+func registerAPI(args []string) error {
+	return registerSpec("register-api", connector.RegistrationKindAPI, args)
+}
+
+func registerEvents(args []string) error {
+	return registerSpec("register-events", connector.RegistrationKindEvents, args)
+}
+
+//registers the sample spec, or a custom one if a spec, file, name, description or label is given
+func registerSpec(command string, kind string, args []string) error {
+	flags := newFlagSet(command)
+	name := connectionFlag(flags)
+	specPath := flags.String("spec", "", kind+" spec file, an open api or async api document or a kyma service, the sample spec is used if empty")
+	file := flags.String("file", "", "name of a document in assets/spec-docs used instead of --spec")
+	specName := flags.String("spec-name", "", "name of the registration, defaults to the name of the service or the title of the document")
+	description := flags.String("description", "", "description of the registration")
+	var labels labelsFlag
+	flags.Var(&labels, "label", "key=value label of the kyma service, can be repeated")
+	var hostURL *string
+	if kind == connector.RegistrationKindAPI {
+		hostURL = flags.String("host-url", "", "target url registered for the api")
+	}
+	force := forceFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	spec := connector.SpecDeclaration{Kind: kind, Name: *specName, Description: *description, File: *file}
+	if hostURL != nil {
+		spec.TargetURL = *hostURL
+	}
+
+	var err error
+	if spec.Labels, err = connector.ParseLabels(labels); err != nil {
+		return err
+	}
+
+	if *specPath != "" {
+		document, err := readInput(*specPath)
+		if err != nil {
+			return err
+		}
+		if spec.Spec, err = json.Marshal(string(document)); err != nil {
+			return err
+		}
+	}
+
+	var resp []byte
+	switch {
+	case len(spec.Spec) > 0 || spec.File != "" || spec.Name != "" || spec.Description != "" || len(spec.Labels) > 0:
+		resp, err = connector.UploadSpec(*name, spec, *force)
+	case kind == connector.RegistrationKindAPI:
+		resp, err = connector.RegisterAPISpec(*name, nil, spec.TargetURL, *force)
+	default:
+		resp, err = connector.RegisterEventSpec(*name, nil, *force)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(resp))
+	return nil
+}
+
+//labelsFlag - a repeatable key=value flag
+type labelsFlag []string
+
+func (labels *labelsFlag) String() string {
+	return strings.Join(*labels, ",")
+}
+
+func (labels *labelsFlag) Set(value string) error {
+	*labels = append(*labels, value)
+	return nil
+}
+
+func specDocs(args []string) error {
+	flags := newFlagSet("spec-docs")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	docs, err := connector.GetSpecDocs()
+	if err != nil {
+		return err
+	}
+	return printJSON(docs)
+}
+
+func registrations(args []string) error {
+	flags := newFlagSet("registrations")
+	name := connectionFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	registrations, err := connector.GetRegistrations(*name)
+	if err != nil {
+		return err
+	}
+	return printJSON(registrations)
+}
+
+//syncManifest - the sync request, specFile is read relative to the manifest instead of spec
+type syncManifest struct {
+	HostURL string `json:"hostURL"`
+	Prune   bool   `json:"prune"`
+	Force   bool   `json:"force"`
+	Specs   []struct {
+		connector.SpecDeclaration
+		SpecFile string `json:"specFile"`
+	} `json:"specs"`
+}
+
+func syncRegistrations(args []string) error {
+	flags := newFlagSet("sync")
+	name := connectionFlag(flags)
+	manifestPath := flags.String("manifest", "", "json file declaring the specs, the sample specs are declared if empty")
+	hostURL := flags.String("host-url", "", "target url of the apis declared without one")
+	prune := flags.Bool("prune", false, "delete registrations made by this app that are no longer declared")
+	force := forceFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	syncReq := connector.SyncRequest{HostURL: *hostURL, Prune: *prune, Force: *force}
+
+	if *manifestPath != "" {
+		manifestData, err := readInput(*manifestPath)
+		if err != nil {
+			return err
+		}
+
+		var manifest syncManifest
+		if err := json.Unmarshal(manifestData, &manifest); err != nil {
+			return fmt.Errorf("could not parse the manifest: %s", err)
+		}
+
+		if syncReq.HostURL == "" {
+			syncReq.HostURL = manifest.HostURL
+		}
+		syncReq.Prune = syncReq.Prune || manifest.Prune
+		syncReq.Force = syncReq.Force || manifest.Force
+
+		for _, spec := range manifest.Specs {
+			if spec.SpecFile != "" {
+				document, err := ioutil.ReadFile(filepath.Join(filepath.Dir(*manifestPath), spec.SpecFile))
+				if err != nil {
+					return err
+				}
+				if spec.Spec, err = json.Marshal(string(document)); err != nil {
+					return err
+				}
+			}
+			syncReq.Specs = append(syncReq.Specs, spec.SpecDeclaration)
+		}
+	}
+
+	report, err := connector.Sync(*name, syncReq)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(report); err != nil {
+		return err
+	}
+
+	if !report.Success {
+		return errors.New("sync failed")
+	}
+	return nil
+}
+
+func updateAPI(args []string) error {
+	flags := newFlagSet("update-api")
+	name := connectionFlag(flags)
+	id := flags.String("id", "", "id of the registered api")
+	specPath := flags.String("spec", "", "api spec file, the sample spec is used if empty")
+	hostURL := flags.String("host-url", "", "target url registered for the api")
+	force := forceFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *id == "" {
+		return errors.New("--id is required")
+	}
+
+	var spec []byte
+	if *specPath != "" {
+		var err error
+		if spec, err = readInput(*specPath); err != nil {
+			return err
+		}
+	}
+
+	resp, err := connector.UpdateRegisteredAPISpec(*name, *id, spec, *hostURL, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(resp))
+	return nil
+}
+
+func updateEvents(args []string) error {
+	flags := newFlagSet("update-events")
+	name := connectionFlag(flags)
+	id := flags.String("id", "", "id of the registered events")
+	specPath := flags.String("spec", "", "event spec file, the sample spec is used if empty")
+	force := forceFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *id == "" {
+		return errors.New("--id is required")
+	}
+
+	var spec []byte
+	if *specPath != "" {
+		var err error
+		if spec, err = readInput(*specPath); err != nil {
+			return err
+		}
+	}
+
+	resp, err := connector.UpdateRegisteredEventSpec(*name, *id, spec, *force)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(resp))
+	return nil
+}
+
+func deleteAPI(args []string) error {
+	return deleteRegistration("delete-api", connector.RegistrationKindAPI, args)
+}
+
+func deleteEvents(args []string) error {
+	return deleteRegistration("delete-events", connector.RegistrationKindEvents, args)
+}
+
+func deleteRegistration(command string, kind string, args []string) error {
+	flags := newFlagSet(command)
+	name := connectionFlag(flags)
+	id := flags.String("id", "", "id of the registration")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *id == "" {
+		return errors.New("--id is required")
+	}
+
+	if err := connector.DeleteRegistration(*name, kind, *id); err != nil {
+		return err
+	}
+	fmt.Printf("Registration %s has been deleted\n", *id)
+	return nil
+}
+
+func validateSpec(args []string) error {
+	flags := newFlagSet("validate")
+	specPath := flags.String("spec", "", "spec file to check, - reads stdin")
+	kind := flags.String("kind", connector.RegistrationKindAPI, "kind of the spec, api (OpenAPI 3) or events (AsyncAPI 1.x/2.x)")
+	service := flags.Bool("service", false, "the file is a rest service envelope, its api and event specs are checked as well")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *specPath == "" {
+		return errors.New("--spec is required")
+	}
+
+	document, err := readInput(*specPath)
+	if err != nil {
+		return err
+	}
+
+	var problems []validate.Problem
+	switch {
+	case *service:
+		problems = validate.Service(document)
+	case *kind == connector.RegistrationKindAPI:
+		problems = validate.OpenAPI(document)
+	case *kind == connector.RegistrationKindEvents:
+		problems = validate.AsyncAPI(document)
+	default:
+		return fmt.Errorf("--kind must be %s or %s", connector.RegistrationKindAPI, connector.RegistrationKindEvents)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+	fmt.Println("The spec is valid")
+	return nil
+}
+
+func openAPI(args []string) error {
+	flags := newFlagSet("openapi")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	document, err := mock.OpenAPI(newRouter())
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(document))
+	return nil
+}
+
+func sendEvent(args []string) error {
+	flags := newFlagSet("send-event")
+	name := connectionFlag(flags)
+	eventType := flags.String("type", "", "event type, e.g. orderCreated")
+	eventTypeVersion := flags.String("version", "v1", "event type version")
+	payload := flags.String("payload", "{}", "json event data, @file reads a file")
+	mode := eventModeFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *eventType == "" {
+		return errors.New("--type is required")
+	}
+
+	data := []byte(*payload)
+	if strings.HasPrefix(*payload, "@") {
+		var err error
+		if data, err = readInput(strings.TrimPrefix(*payload, "@")); err != nil {
+			return err
+		}
+	}
+
+	if !json.Valid(data) {
+		return errors.New("--payload is not valid json")
+	}
+
+	resp, err := connector.PublishEvent(*name, *mode, *eventType, *eventTypeVersion, data)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(resp))
+	return nil
+}
+
+//adds the flag selecting how events are published
+func eventModeFlag(flags *flag.FlagSet) *string {
+	return flags.String("event-mode", "", "legacy, structured or binary, defaults to the mode of the connection or "+connector.EventModeEnv)
+}
+
+func eventMode(args []string) error {
+	flags := newFlagSet("event-mode")
+	name := connectionFlag(flags)
+	mode := flags.String("mode", "", "legacy, structured or binary, empty falls back to "+connector.EventModeEnv)
+	eventURL := flags.String("url", "", "url events are published to instead of the events url of the system")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	info, err := connector.SetConnectionEventMode(*name, connector.EventModeRequest{Mode: *mode, URL: *eventURL})
+	if err != nil {
+		return err
+	}
+	return printJSON(info)
+}
+
+func disconnect(args []string) error {
+	flags := newFlagSet("disconnect")
+	name := connectionFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if err := connector.DisconnectConnection(*name); err != nil {
+		return err
+	}
+	fmt.Println("Connection has been removed")
+	return nil
+}
+
+func fakeKyma(args []string) error {
+	flags := newFlagSet("fake-kyma")
+	application := flags.String("app", "demo-app", "name of the application")
+	host := flags.String("host", "localhost", "host name used in the advertised urls")
+	addr := flags.String("addr", ":9000", "address of the connector (token and csr) endpoints")
+	gatewayAddr := flags.String("gateway-addr", ":9443", "address of the client certificate secured endpoints")
+	validity := flags.Duration("cert-validity", fake.DefaultCertificateValidity, "lifetime of the issued client certificates")
+	keyAlgorithm := flags.String("key-algorithm", fake.DefaultKeyAlgorithm, "key algorithm advertised for the csr, e.g. rsa2048 or ecdsa-p256")
+	extensions := flags.String("cert-extensions", "", "extensions advertised for the csr, e.g. extendedKeyUsage=clientAuth")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	kyma, err := fake.NewKyma(*application)
+	if err != nil {
+		return err
+	}
+	kyma.CertificateValidity = *validity
+	kyma.KeyAlgorithm = *keyAlgorithm
+	kyma.CertificateExtensions = *extensions
+
+	return kyma.ListenAndServe(*host, *addr, *gatewayAddr)
+}
+
+func fakeCompass(args []string) error {
+	flags := newFlagSet("fake-compass")
+	application := flags.String("app", "demo-app", "name of the application")
+	host := flags.String("host", "localhost", "host name used in the advertised urls")
+	addr := flags.String("addr", ":9100", "address of the token authenticated connector")
+	gatewayAddr := flags.String("gateway-addr", ":9543", "address of the client certificate secured connector and director")
+	validity := flags.Duration("cert-validity", fake.DefaultCertificateValidity, "lifetime of the issued client certificates")
+	keyAlgorithm := flags.String("key-algorithm", fake.DefaultKeyAlgorithm, "key algorithm advertised for the csr, e.g. rsa2048 or ecdsa-p256")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	compass, err := fake.NewCompass(*application)
+	if err != nil {
+		return err
+	}
+	compass.CertificateValidity = *validity
+	compass.KeyAlgorithm = *keyAlgorithm
+
+	return compass.ListenAndServe(*host, *addr, *gatewayAddr)
+}
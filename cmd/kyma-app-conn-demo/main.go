@@ -1,8 +1,11 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 	"github.com/jcawley/kyma-app-connector/internal"
@@ -12,6 +15,49 @@ import (
 
 func main() {
 
+	//the sample api spec describes the mock routes registered by the server
+	connector.SetAPISpecGenerator(func() ([]byte, error) {
+		return mock.OpenAPI(newRouter())
+	})
+	//the sample api spec is registered with the credentials the mock api expects, the mock api then expects
+	//the default instance auth of a compass package once one is registered
+	connector.SetSampleAuth(mock.Auth)
+	connector.SetAuthRegistered(func(auth connector.AuthConfig) {
+		if err := mock.UseAuth(auth); err != nil {
+			log.Printf("the mock api keeps its auth: %s", err)
+		}
+	})
+
+	if len(os.Args) < 2 {
+		serve(nil)
+		return
+	}
+
+	switch os.Args[1] {
+	case "help", "-h", "--help":
+		usage()
+		return
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	err := command.run(os.Args[2:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func newRouter() *mux.Router {
+
 	router := mux.NewRouter().StrictSlash(true)
 
 	router.HandleFunc("/", internal.IndexHandler)
@@ -20,12 +66,55 @@ func main() {
 	router.HandleFunc("/api/getAppInfo", connector.GetAppInfo)
 	router.HandleFunc("/api/sendAPISpec", connector.SendAPISpec)
 	router.HandleFunc("/api/sendEventSpec", connector.SendEventSpec)
+	router.HandleFunc("/api/registrations", connector.ListRegistrations).Methods("GET")
+	router.HandleFunc("/api/specDocs", connector.ListSpecDocs).Methods("GET")
+	router.HandleFunc("/api/sync", connector.SyncRegistrations).Methods("POST")
+	router.HandleFunc("/api/updateAPISpec/{id}", connector.UpdateAPISpec)
+	router.HandleFunc("/api/updateEventSpec/{id}", connector.UpdateEventSpec)
+	router.HandleFunc("/api/deleteAPISpec/{id}", connector.DeleteAPISpec)
+	router.HandleFunc("/api/deleteEventSpec/{id}", connector.DeleteEventSpec)
+	router.HandleFunc("/api/renewCertificate", connector.RenewCertificate)
+	router.HandleFunc("/api/disconnect", connector.Disconnect)
+	router.HandleFunc("/api/connect", connector.ConnectAll).Methods("POST")
+	router.HandleFunc("/api/eventMode", connector.SetEventMode).Methods("POST")
+
+	router.HandleFunc("/api/connections", connector.ListConnections)
+	connRouter := router.PathPrefix("/api/connections/{name:" + connector.ConnectionNamePattern + "}").Subrouter()
+	connRouter.HandleFunc("/callTokenURL", connector.CallTokenURL)
+	connRouter.HandleFunc("/createSecureConnection", connector.CreateSecureConnection)
+	connRouter.HandleFunc("/getAppInfo", connector.GetAppInfo)
+	connRouter.HandleFunc("/sendAPISpec", connector.SendAPISpec)
+	connRouter.HandleFunc("/sendEventSpec", connector.SendEventSpec)
+	connRouter.HandleFunc("/registrations", connector.ListRegistrations).Methods("GET")
+	connRouter.HandleFunc("/sync", connector.SyncRegistrations).Methods("POST")
+	connRouter.HandleFunc("/updateAPISpec/{id}", connector.UpdateAPISpec)
+	connRouter.HandleFunc("/updateEventSpec/{id}", connector.UpdateEventSpec)
+	connRouter.HandleFunc("/deleteAPISpec/{id}", connector.DeleteAPISpec)
+	connRouter.HandleFunc("/deleteEventSpec/{id}", connector.DeleteEventSpec)
+	connRouter.HandleFunc("/renewCertificate", connector.RenewCertificate)
+	connRouter.HandleFunc("/disconnect", connector.Disconnect)
+	connRouter.HandleFunc("/connect", connector.ConnectAll).Methods("POST")
+	connRouter.HandleFunc("/eventMode", connector.SetEventMode).Methods("POST")
+	connRouter.HandleFunc("/sendOrderCreatedEvent", mock.SendOrderCreatedEvent)
+
 	router.HandleFunc("/orders/sendOrderCreatedEvent", mock.SendOrderCreatedEvent)
-	router.HandleFunc("/orders", mock.GetOrders).Methods("GET")
-	router.HandleFunc("/orders/{id}", mock.GetOrder).Methods("GET")
-	router.HandleFunc("/orders", mock.PostOrders).Methods("POST")
+	router.HandleFunc("/openapi.json", mock.ServeOpenAPI(router)).Methods("GET")
+	mock.RegisterRoutes(router)
+
+	return router
+}
+
+func serve(args []string) error {
+
+	flags := newFlagSet("serve")
+	addr := flags.String("addr", ":8000", "address the server listens on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
 
-	log.Fatal(http.ListenAndServe(":8000", router))
+	connector.Restore()
+	log.Fatal(http.ListenAndServe(*addr, newRouter()))
 
 	// mock.StartMockServer()
+	return nil
 }
@@ -12,14 +12,15 @@ import (
 //IndexHandler -
 func IndexHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("IndexHandler")
-	status := connector.GetConnectionStatus()
 	assetsDir := connector.GetAssetsDir()
 
 	type Status struct {
-		ConnectionStatus string
+		ConnectionName string
+		Connections    []connector.ConnectionInfo
 	}
 	pageData := Status{
-		ConnectionStatus: status,
+		ConnectionName: connector.DefaultConnection,
+		Connections:    connector.GetConnections(),
 	}
 
 	tmpl := template.Must(template.ParseFiles(filepath.Join(assetsDir, "/templates/index.html")))
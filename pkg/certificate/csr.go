@@ -2,13 +2,11 @@ package cert
 
 import (
 	"crypto/rand"
-	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
 	"encoding/pem"
 	"math/big"
-	"strings"
 	"time"
 )
 
@@ -19,62 +17,85 @@ type KymaCerts struct {
 	CSR        []byte
 }
 
-//GenerateCSR - generate a csr based on the received subject
-//subject - csr subject string
-func GenerateCSR(subject string, keylength int) (*KymaCerts, error) {
-
-	keyBytes, _ := rsa.GenerateKey(rand.Reader, keylength)
+//CSROptions - optional content of the csr
+type CSROptions struct {
+	//extensions as advertised by the kyma connector, see applyExtensions
+	Extensions string
+	//subject alternative names, e.g. DNS:app.example.com, IP:10.0.0.1, URI:spiffe://app or email:app@example.com
+	SubjectAltNames []string
+}
 
-	subjectTrimed := strings.TrimSuffix(subject, ",")
-	entries := strings.Split(subjectTrimed, ",")
-	subjectMapped := make(map[string]string)
+//GenerateCSR - generate a csr based on the received subject
+//subject - csr subject string in the format of RFC 4514
+//keyAlgorithm - the key algorithm of the private key, see ParseKeyAlgorithm
+func GenerateCSR(subject string, keyAlgorithm string, options CSROptions) (*KymaCerts, error) {
 
-	for _, e := range entries {
-		parts := strings.Split(e, "=")
-		subjectMapped[parts[0]] = parts[1]
+	rdns, err := ParseDN(subject)
+	if err != nil {
+		return nil, err
 	}
 
-	subj := pkix.Name{
-		CommonName:         subjectMapped["CN"],
-		Country:            []string{subjectMapped["C"]},
-		Province:           []string{subjectMapped["ST"]},
-		Locality:           []string{subjectMapped["L"]},
-		Organization:       []string{subjectMapped["O"]},
-		OrganizationalUnit: []string{subjectMapped["OU"]},
+	rawSubject, err := asn1.Marshal(rdns)
+	if err != nil {
+		return nil, err
 	}
 
-	type basicConstraints struct {
-		IsCA       bool `asn1:"optional"`
-		MaxPathLen int  `asn1:"optional,default:-1"`
+	var subj pkix.Name
+	subj.FillFromRDNSequence(&rdns)
+
+	keyBytes, signatureAlgorithm, privateKey, err := generateKey(keyAlgorithm)
+	if err != nil {
+		return nil, err
 	}
 
-	val, _ := asn1.Marshal(basicConstraints{true, 0})
+	val, err := asn1.Marshal(basicConstraints{true, 0})
+	if err != nil {
+		return nil, err
+	}
 
 	var csrTemplate = x509.CertificateRequest{
-		Subject:            subj,
-		SignatureAlgorithm: x509.SHA256WithRSA,
+		RawSubject:         rawSubject,
+		SignatureAlgorithm: signatureAlgorithm,
 		ExtraExtensions: []pkix.Extension{
 			{
-				Id:       asn1.ObjectIdentifier{2, 5, 29, 19},
+				Id:       oidExtensionBasicConstraints,
 				Value:    val,
 				Critical: true,
 			},
 		},
 	}
 
-	csrBytes, _ := x509.CreateCertificateRequest(rand.Reader, &csrTemplate, keyBytes)
+	//the names are added first, a critical subjectAltName of the extensions includes them
+	for _, name := range options.SubjectAltNames {
+		if err := addSubjectAltName(&csrTemplate, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyExtensions(&csrTemplate, options.Extensions); err != nil {
+		return nil, err
+	}
+
+	csrBytes, err := x509.CreateCertificateRequest(rand.Reader, &csrTemplate, keyBytes)
+	if err != nil {
+		return nil, err
+	}
 
 	csr := pem.EncodeToMemory(&pem.Block{
 		Type: "CERTIFICATE REQUEST", Bytes: csrBytes,
 	})
 
 	// step: generate a serial number
-	serial, _ := rand.Int(rand.Reader, (&big.Int{}).Exp(big.NewInt(2), big.NewInt(159), nil))
+	serial, err := rand.Int(rand.Reader, (&big.Int{}).Exp(big.NewInt(2), big.NewInt(159), nil))
+	if err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	// step: create the request template
 	template := x509.Certificate{
 		SerialNumber:          serial,
+		RawSubject:            rawSubject,
 		Subject:               subj,
 		NotBefore:             now.Add(-10 * time.Minute).UTC(),
 		NotAfter:              now.Add(time.Duration(1200)).UTC(),
@@ -85,12 +106,13 @@ func GenerateCSR(subject string, keylength int) (*KymaCerts, error) {
 	}
 
 	// step: sign the certificate authority
-	certificate, _ := x509.CreateCertificate(rand.Reader, &template, &template, &keyBytes.PublicKey, keyBytes)
+	certificate, err := x509.CreateCertificate(rand.Reader, &template, &template, keyBytes.Public(), keyBytes)
+	if err != nil {
+		return nil, err
+	}
 
 	clientCrt := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
 
-	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(keyBytes)})
-
 	return &KymaCerts{
 		PrivateKey: privateKey,
 		CRT:        clientCrt,
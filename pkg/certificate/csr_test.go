@@ -0,0 +1,187 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSubject string = "CN=test-app,OU=OrgUnit,O=Organization,L=Waldorf,ST=Waldorf,C=DE"
+
+func generateTestCSR(t *testing.T, options CSROptions) *x509.CertificateRequest {
+
+	certs, err := GenerateCSR(testSubject, KeyAlgorithmECDSAP256, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(certs.CSR)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		t.Fatalf("the csr is not a PEM encoded certificate request: %s", certs.CSR)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatal(err)
+	}
+	return csr
+}
+
+func findExtension(t *testing.T, csr *x509.CertificateRequest, oid asn1.ObjectIdentifier) pkix.Extension {
+	for _, extension := range csr.Extensions {
+		if extension.Id.Equal(oid) {
+			return extension
+		}
+	}
+	t.Fatalf("the csr has no extension %s", oid)
+	return pkix.Extension{}
+}
+
+func TestGenerateCSRSubjectAltNames(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		extensions string
+		critical   bool
+	}{
+		{"not critical", "subjectAltName=DNS:app.example.com,IP:10.0.0.1", false},
+		{"critical", "subjectAltName=critical,DNS:app.example.com,IP:10.0.0.1", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			csr := generateTestCSR(t, CSROptions{
+				Extensions:      test.extensions,
+				SubjectAltNames: []string{"URI:spiffe://test-app", "email:app@example.com", "other.example.com"},
+			})
+
+			if expected := []string{"other.example.com", "app.example.com"}; !reflect.DeepEqual(csr.DNSNames, expected) {
+				t.Errorf("DNSNames = %v, want %v", csr.DNSNames, expected)
+			}
+			if len(csr.IPAddresses) != 1 || !csr.IPAddresses[0].Equal(net.ParseIP("10.0.0.1")) {
+				t.Errorf("IPAddresses = %v, want 10.0.0.1", csr.IPAddresses)
+			}
+			if len(csr.URIs) != 1 || csr.URIs[0].String() != "spiffe://test-app" {
+				t.Errorf("URIs = %v, want spiffe://test-app", csr.URIs)
+			}
+			if expected := []string{"app@example.com"}; !reflect.DeepEqual(csr.EmailAddresses, expected) {
+				t.Errorf("EmailAddresses = %v, want %v", csr.EmailAddresses, expected)
+			}
+
+			if extension := findExtension(t, csr, oidExtensionSubjectAltName); extension.Critical != test.critical {
+				t.Errorf("the subjectAltName extension is critical = %t, want %t", extension.Critical, test.critical)
+			}
+		})
+	}
+}
+
+func TestGenerateCSRExtensions(t *testing.T) {
+
+	csr := generateTestCSR(t, CSROptions{
+		Extensions: "keyUsage=critical,digitalSignature,keyEncipherment;extendedKeyUsage=critical,clientAuth,1.2.3.4\n" +
+			"basicConstraints=CA:FALSE;1.2.3.5=DER:0500",
+	})
+
+	if csr.Subject.CommonName != "test-app" || csr.Subject.Country[0] != "DE" {
+		t.Errorf("subject = %s, want %s", csr.Subject, testSubject)
+	}
+
+	keyUsage := findExtension(t, csr, oidExtensionKeyUsage)
+	var bits asn1.BitString
+	if _, err := asn1.Unmarshal(keyUsage.Value, &bits); err != nil {
+		t.Fatal(err)
+	}
+	//digitalSignature and keyEncipherment are the bits 0 and 2
+	if !keyUsage.Critical || bits.BitLength != 3 || bits.At(0) != 1 || bits.At(1) != 0 || bits.At(2) != 1 {
+		t.Errorf("key usage = %+v critical %t, want digitalSignature and keyEncipherment", bits, keyUsage.Critical)
+	}
+
+	extendedKeyUsage := findExtension(t, csr, oidExtensionExtendedKeyUsage)
+	var usages []asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(extendedKeyUsage.Value, &usages); err != nil {
+		t.Fatal(err)
+	}
+	expectedUsages := []asn1.ObjectIdentifier{extendedKeyUsages["clientauth"], {1, 2, 3, 4}}
+	if !extendedKeyUsage.Critical || !reflect.DeepEqual(usages, expectedUsages) {
+		t.Errorf("extended key usage = %v critical %t, want %v", usages, extendedKeyUsage.Critical, expectedUsages)
+	}
+
+	//replaces the default basic constraints of a ca
+	constraints := findExtension(t, csr, oidExtensionBasicConstraints)
+	var parsed basicConstraints
+	if _, err := asn1.Unmarshal(constraints.Value, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !constraints.Critical || parsed.IsCA || parsed.MaxPathLen != -1 {
+		t.Errorf("basic constraints = %+v critical %t, want no ca", parsed, constraints.Critical)
+	}
+
+	raw := findExtension(t, csr, asn1.ObjectIdentifier{1, 2, 3, 5})
+	if raw.Critical || !reflect.DeepEqual(raw.Value, []byte{0x05, 0x00}) {
+		t.Errorf("raw extension = %x critical %t, want 0500", raw.Value, raw.Critical)
+	}
+
+	count := 0
+	for _, extension := range csr.Extensions {
+		if extension.Id.Equal(oidExtensionBasicConstraints) {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("the csr has %d basic constraints", count)
+	}
+}
+
+func TestGenerateCSRDefaultBasicConstraints(t *testing.T) {
+
+	csr := generateTestCSR(t, CSROptions{})
+
+	constraints := findExtension(t, csr, oidExtensionBasicConstraints)
+	var parsed basicConstraints
+	if _, err := asn1.Unmarshal(constraints.Value, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !constraints.Critical || !parsed.IsCA || parsed.MaxPathLen != 0 {
+		t.Errorf("basic constraints = %+v critical %t, want a ca with path length 0", parsed, constraints.Critical)
+	}
+}
+
+func TestGenerateCSRRejectsInvalidExtensions(t *testing.T) {
+
+	tests := []struct {
+		extensions string
+		reason     string
+	}{
+		{"subjectAltName", "expected name=value"},
+		{"subjectAltName=critical", "no subject alternative name given"},
+		{"subjectAltName=critical,DNS:müller.example.com", "is not an IA5 string"},
+		{"subjectAltName=IP:10.0.0", "invalid ip address"},
+		{"keyUsage=critical", "no key usage given"},
+		{"keyUsage=signEverything", `unknown key usage "signEverything"`},
+		{"extendedKeyUsage=anyAuth", `unknown extended key usage "anyAuth"`},
+		{"basicConstraints=CA:maybe", `invalid basic constraint "CA:maybe"`},
+		{"basicConstraints=pathlen:-1", `invalid basic constraint "pathlen:-1"`},
+		{"basicConstraints=depth:1", `unknown basic constraint "depth:1"`},
+		{"nameConstraints=permitted", `unknown extension "nameConstraints"`},
+		{"1.2.3.4=0500", "expected a single DER:<hex> value"},
+		{"1.2.3.4=DER:zz", "invalid DER value"},
+	}
+
+	for _, test := range tests {
+		_, err := GenerateCSR(testSubject, KeyAlgorithmECDSAP256, CSROptions{Extensions: test.extensions})
+		if err == nil {
+			t.Errorf("%s: GenerateCSR succeeded, want an error", test.extensions)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("%s: error = %q, want %q", test.extensions, err, test.reason)
+		}
+	}
+}
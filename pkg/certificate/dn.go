@@ -0,0 +1,301 @@
+package cert
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+//attribute types of RFC 4514 and the ones commonly used in subjects
+var attributeTypes = map[string]asn1.ObjectIdentifier{
+	"CN":           {2, 5, 4, 3},
+	"SERIALNUMBER": {2, 5, 4, 5},
+	"C":            {2, 5, 4, 6},
+	"L":            {2, 5, 4, 7},
+	"ST":           {2, 5, 4, 8},
+	"STREET":       {2, 5, 4, 9},
+	"O":            {2, 5, 4, 10},
+	"OU":           {2, 5, 4, 11},
+	"POSTALCODE":   {2, 5, 4, 17},
+	"DC":           {0, 9, 2342, 19200300, 100, 1, 25},
+	"UID":          {0, 9, 2342, 19200300, 100, 1, 1},
+	"EMAILADDRESS": {1, 2, 840, 113549, 1, 9, 1},
+	"E":            {1, 2, 840, 113549, 1, 9, 1},
+}
+
+//attributes encoded as IA5String instead of a printable or utf8 string
+var ia5AttributeTypes = []asn1.ObjectIdentifier{
+	attributeTypes["DC"],
+	attributeTypes["EMAILADDRESS"],
+}
+
+//ParseDN - parses a distinguished name in the string format of RFC 4514, e.g. CN=app,OU=Org\, Unit+L=Waldorf,C=DE
+//the returned sequence is in ASN.1 order, which is the reverse of the string order
+func ParseDN(dn string) (pkix.RDNSequence, error) {
+
+	parser := dnParser{input: dn}
+
+	var rdns pkix.RDNSequence
+	var rdn pkix.RelativeDistinguishedNameSET
+
+	for {
+		parser.skipSpaces()
+		if parser.done() {
+			//a trailing separator is tolerated, the connector returned subjects ending with ","
+			if len(rdn) > 0 {
+				return nil, parser.errorf("missing attribute after \"+\"")
+			}
+			break
+		}
+
+		attribute, err := parser.attribute()
+		if err != nil {
+			return nil, err
+		}
+		rdn = append(rdn, attribute)
+
+		if parser.done() {
+			rdns = append(rdns, rdn)
+			break
+		}
+
+		switch parser.next() {
+		case '+':
+			continue
+		case ',', ';':
+			rdns = append(rdns, rdn)
+			rdn = nil
+		}
+	}
+
+	if len(rdns) == 0 {
+		return nil, fmt.Errorf("empty distinguished name")
+	}
+
+	for i, j := 0, len(rdns)-1; i < j; i, j = i+1, j-1 {
+		rdns[i], rdns[j] = rdns[j], rdns[i]
+	}
+
+	return rdns, nil
+}
+
+type dnParser struct {
+	input    string
+	position int
+}
+
+func (parser *dnParser) done() bool {
+	return parser.position >= len(parser.input)
+}
+
+func (parser *dnParser) peek() byte {
+	return parser.input[parser.position]
+}
+
+func (parser *dnParser) next() byte {
+	c := parser.input[parser.position]
+	parser.position++
+	return c
+}
+
+func (parser *dnParser) skipSpaces() {
+	for !parser.done() && parser.peek() == ' ' {
+		parser.position++
+	}
+}
+
+func (parser *dnParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid distinguished name %q at position %d: %s", parser.input, parser.position, fmt.Sprintf(format, args...))
+}
+
+//attributeTypeAndValue = attributeType "=" attributeValue
+func (parser *dnParser) attribute() (pkix.AttributeTypeAndValue, error) {
+
+	start := parser.position
+	for !parser.done() && parser.peek() != '=' {
+		switch parser.peek() {
+		case ',', '+', ';':
+			return pkix.AttributeTypeAndValue{}, parser.errorf("missing \"=\" after %q", parser.input[start:parser.position])
+		}
+		parser.position++
+	}
+	if parser.done() {
+		return pkix.AttributeTypeAndValue{}, parser.errorf("missing \"=\" after %q", parser.input[start:])
+	}
+
+	oid, err := parseAttributeType(strings.TrimSpace(parser.input[start:parser.position]))
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, parser.errorf("%s", err)
+	}
+	parser.next()
+	parser.skipSpaces()
+
+	if !parser.done() && parser.peek() == '#' {
+		value, err := parser.hexValue()
+		return pkix.AttributeTypeAndValue{Type: oid, Value: value}, err
+	}
+
+	value, err := parser.stringValue()
+	if err != nil {
+		return pkix.AttributeTypeAndValue{}, err
+	}
+
+	for _, ia5Type := range ia5AttributeTypes {
+		if oid.Equal(ia5Type) {
+			encoded, err := asn1.MarshalWithParams(value, "ia5")
+			if err != nil {
+				return pkix.AttributeTypeAndValue{}, parser.errorf("%s is not an IA5 string", value)
+			}
+			return pkix.AttributeTypeAndValue{Type: oid, Value: asn1.RawValue{FullBytes: encoded}}, nil
+		}
+	}
+
+	return pkix.AttributeTypeAndValue{Type: oid, Value: value}, nil
+}
+
+//a short name like CN or a numeric oid, optionally prefixed with "OID."
+func parseAttributeType(attributeType string) (asn1.ObjectIdentifier, error) {
+
+	if attributeType == "" {
+		return nil, fmt.Errorf("missing attribute type")
+	}
+
+	if oid, ok := attributeTypes[strings.ToUpper(attributeType)]; ok {
+		return oid, nil
+	}
+
+	numericOID := attributeType
+	if strings.HasPrefix(strings.ToUpper(numericOID), "OID.") {
+		numericOID = numericOID[len("OID."):]
+	}
+
+	oid, err := parseOID(numericOID)
+	if err != nil {
+		return nil, fmt.Errorf("unknown attribute type %q", attributeType)
+	}
+	return oid, nil
+}
+
+//"#" followed by the hex encoded BER value
+func (parser *dnParser) hexValue() (asn1.RawValue, error) {
+
+	parser.next()
+	start := parser.position
+	for !parser.done() && !isSeparator(parser.peek()) && parser.peek() != ' ' {
+		parser.position++
+	}
+	encoded := parser.input[start:parser.position]
+	parser.skipSpaces()
+
+	if !parser.done() && !isSeparator(parser.peek()) {
+		return asn1.RawValue{}, parser.errorf("unexpected character %q after hex value", parser.peek())
+	}
+
+	der, err := hex.DecodeString(encoded)
+	if err != nil {
+		return asn1.RawValue{}, parser.errorf("invalid hex value %q", encoded)
+	}
+
+	var value asn1.RawValue
+	rest, err := asn1.Unmarshal(der, &value)
+	if err != nil || len(rest) > 0 {
+		return asn1.RawValue{}, parser.errorf("invalid BER value %q", encoded)
+	}
+	return value, nil
+}
+
+//a string with backslash escapes, or a quoted string as allowed by RFC 2253
+func (parser *dnParser) stringValue() (string, error) {
+
+	if !parser.done() && parser.peek() == '"' {
+		return parser.quotedValue()
+	}
+
+	var value []byte
+	//unescaped trailing spaces are not part of the value
+	significant := 0
+
+	for !parser.done() && !isSeparator(parser.peek()) {
+		c := parser.next()
+
+		switch c {
+		case '\\':
+			escaped, err := parser.escaped()
+			if err != nil {
+				return "", err
+			}
+			value = append(value, escaped)
+			significant = len(value)
+		case '"':
+			return "", parser.errorf("unescaped \"\\\"\" in value")
+		default:
+			value = append(value, c)
+			if c != ' ' {
+				significant = len(value)
+			}
+		}
+	}
+
+	return string(value[:significant]), nil
+}
+
+func (parser *dnParser) quotedValue() (string, error) {
+
+	parser.next()
+
+	var value []byte
+	for {
+		if parser.done() {
+			return "", parser.errorf("missing closing quote")
+		}
+
+		c := parser.next()
+		if c == '"' {
+			break
+		}
+
+		if c == '\\' {
+			escaped, err := parser.escaped()
+			if err != nil {
+				return "", err
+			}
+			c = escaped
+		}
+		value = append(value, c)
+	}
+
+	parser.skipSpaces()
+	if !parser.done() && !isSeparator(parser.peek()) {
+		return "", parser.errorf("unexpected character %q after quoted value", parser.peek())
+	}
+	return string(value), nil
+}
+
+//the character after a backslash: a special character or two hex digits of a utf-8 byte
+func (parser *dnParser) escaped() (byte, error) {
+
+	if parser.done() {
+		return 0, parser.errorf("incomplete escape sequence")
+	}
+
+	c := parser.next()
+	if strings.IndexByte(",+\"\\<>;=# ", c) >= 0 {
+		return c, nil
+	}
+
+	if parser.done() {
+		return 0, parser.errorf("invalid escape sequence \"\\%c\"", c)
+	}
+
+	decoded, err := hex.DecodeString(string([]byte{c, parser.next()}))
+	if err != nil {
+		return 0, parser.errorf("invalid escape sequence")
+	}
+	return decoded[0], nil
+}
+
+func isSeparator(c byte) bool {
+	return c == ',' || c == '+' || c == ';'
+}
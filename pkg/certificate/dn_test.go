@@ -0,0 +1,166 @@
+package cert
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var attributeNames = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"0.9.2342.19200300.100.1.25": "DC",
+}
+
+//the attributes of each rdn as TYPE=value, raw values as # followed by their hex encoding
+func formatRDNs(rdns pkix.RDNSequence) [][]string {
+
+	formatted := [][]string{}
+	for _, rdn := range rdns {
+		attributes := []string{}
+		for _, attribute := range rdn {
+			name, ok := attributeNames[attribute.Type.String()]
+			if !ok {
+				name = attribute.Type.String()
+			}
+
+			switch value := attribute.Value.(type) {
+			case string:
+				attributes = append(attributes, name+"="+value)
+			case asn1.RawValue:
+				attributes = append(attributes, name+"=#"+hex.EncodeToString(value.FullBytes))
+			default:
+				attributes = append(attributes, name+"=?")
+			}
+		}
+		formatted = append(formatted, attributes)
+	}
+	return formatted
+}
+
+func TestParseDN(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		dn       string
+		expected [][]string
+	}{
+		//the sequence is in ASN.1 order, the reverse of the string
+		{"reversed order", "CN=app,O=SAP,C=DE", [][]string{{"C=DE"}, {"O=SAP"}, {"CN=app"}}},
+		{"escaped comma", `CN=app,OU=Org\, Unit,C=DE`, [][]string{{"C=DE"}, {"OU=Org, Unit"}, {"CN=app"}}},
+		{"escaped plus", `CN=a\+b,C=DE`, [][]string{{"C=DE"}, {"CN=a+b"}}},
+		{"escaped specials", `CN=\"a\"\\\<b\>\;\=\#`, [][]string{{`CN="a"\<b>;=#`}}},
+		{"multi-valued", `CN=app,OU=Unit+L=Waldorf,C=DE`, [][]string{{"C=DE"}, {"OU=Unit", "L=Waldorf"}, {"CN=app"}}},
+		{"multi-valued with escaped plus", `OU=a\+b+L=c\,d`, [][]string{{"OU=a+b", "L=c,d"}}},
+		{"hex escapes", `CN=M\C3\BCller`, [][]string{{"CN=Müller"}}},
+		{"quoted", `CN="app, Inc.",C=DE`, [][]string{{"C=DE"}, {"CN=app, Inc."}}},
+		{"spaces", ` CN = app , C=DE `, [][]string{{"C=DE"}, {"CN=app"}}},
+		{"escaped trailing space", `CN=app\ `, [][]string{{"CN=app "}}},
+		{"semicolon separator", "CN=app;C=DE", [][]string{{"C=DE"}, {"CN=app"}}},
+		{"trailing separator", "CN=app,C=DE,", [][]string{{"C=DE"}, {"CN=app"}}},
+		{"lower case type", "cn=app", [][]string{{"CN=app"}}},
+		{"numeric oid", "2.5.4.3=app,OID.2.5.4.6=DE", [][]string{{"C=DE"}, {"CN=app"}}},
+		{"hex value", "CN=#0c03617070", [][]string{{"CN=#0c03617070"}}},
+		{"ia5 attribute", "DC=example", [][]string{{"DC=#16076578616d706c65"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rdns, err := ParseDN(test.dn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := formatRDNs(rdns); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("ParseDN(%q) = %q, want %q", test.dn, actual, test.expected)
+			}
+		})
+	}
+}
+
+//the sequence is what the standard library expects, its string form is the parsed one
+func TestParseDNRoundTrip(t *testing.T) {
+
+	for _, dn := range []string{
+		"CN=app,O=SAP,C=DE",
+		`CN=app,OU=Org\, Unit+L=Waldorf,C=DE`,
+		`CN=a\+b,O=c\;d`,
+	} {
+		rdns, err := ParseDN(dn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := rdns.String(); actual != dn {
+			t.Errorf("ParseDN(%q).String() = %q", dn, actual)
+		}
+	}
+}
+
+func TestParseDNRejectsMalformed(t *testing.T) {
+
+	tests := []struct {
+		dn     string
+		reason string
+	}{
+		{"", "empty distinguished name"},
+		{"   ", "empty distinguished name"},
+		{",", `missing "=" after ""`},
+		{"CN=app,,C=DE", `missing "=" after ""`},
+		{"CN", `missing "=" after "CN"`},
+		{"CN=app,OU", `missing "=" after "OU"`},
+		{"CN,OU=app", `missing "=" after "CN"`},
+		{"=app", "missing attribute type"},
+		{"XX=app", `unknown attribute type "XX"`},
+		{"1=app", `unknown attribute type "1"`},
+		{"OID.=app", `unknown attribute type "OID."`},
+		{"CN=app+", `missing attribute after "+"`},
+		{`CN=app\`, "incomplete escape sequence"},
+		{`CN=app\4`, `invalid escape sequence "\4"`},
+		{`CN=app\zz`, "invalid escape sequence"},
+		{`CN=a"b`, `unescaped "\"" in value`},
+		{`CN="app`, "missing closing quote"},
+		{`CN="app"x`, `unexpected character 'x' after quoted value`},
+		{"CN=#", `invalid BER value ""`},
+		{"CN=#0c0", `invalid hex value "0c0"`},
+		{"CN=#0c03617070ff", `invalid BER value "0c03617070ff"`},
+		{"CN=#0c03617070 x", `unexpected character 'x' after hex value`},
+		{"DC=müller", "is not an IA5 string"},
+	}
+
+	for _, test := range tests {
+		rdns, err := ParseDN(test.dn)
+		if err == nil {
+			t.Errorf("ParseDN(%q) = %v, want an error", test.dn, rdns)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("ParseDN(%q) error = %q, want %q", test.dn, err, test.reason)
+		}
+	}
+}
+
+//every prefix of a name is either parsed or refused with an error
+func TestParseDNTruncated(t *testing.T) {
+
+	for _, dn := range []string{
+		`CN=app,OU=Org\, Unit+L=Waldorf,C=DE`,
+		`CN="a\"b, c",DC=#16076578616d706c65`,
+		`OID.2.5.4.3=M\C3\BCller\ ;C=DE`,
+	} {
+		for i := 0; i <= len(dn); i++ {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("ParseDN(%q) panicked: %v", dn[:i], r)
+					}
+				}()
+				ParseDN(dn[:i])
+			}()
+		}
+	}
+}
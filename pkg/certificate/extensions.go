@@ -0,0 +1,369 @@
+package cert
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtensionSubjectAltName   = asn1.ObjectIdentifier{2, 5, 29, 17}
+)
+
+//context specific tags of the general names of RFC 5280
+const generalNameEmail int = 1
+const generalNameDNS int = 2
+const generalNameURI int = 6
+const generalNameIP int = 7
+
+//bit positions of RFC 5280
+var keyUsages = map[string]int{
+	"digitalsignature":  0,
+	"nonrepudiation":    1,
+	"contentcommitment": 1,
+	"keyencipherment":   2,
+	"dataencipherment":  3,
+	"keyagreement":      4,
+	"keycertsign":       5,
+	"crlsign":           6,
+	"encipheronly":      7,
+	"decipheronly":      8,
+}
+
+var extendedKeyUsages = map[string]asn1.ObjectIdentifier{
+	"serverauth":      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	"clientauth":      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	"codesigning":     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	"emailprotection": {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	"timestamping":    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	"ocspsigning":     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+type basicConstraints struct {
+	IsCA       bool `asn1:"optional"`
+	MaxPathLen int  `asn1:"optional,default:-1"`
+}
+
+//applyExtensions - adds the extensions to the csr template
+//entries are separated by ";" or new lines and use the syntax of an openssl config, e.g.
+//subjectAltName=DNS:app.example.com,IP:10.0.0.1;keyUsage=critical,digitalSignature;extendedKeyUsage=clientAuth
+//other extensions are given by their oid: 1.2.3.4=critical,DER:0500
+//a critical subjectAltName includes the names already added to the template
+func applyExtensions(template *x509.CertificateRequest, extensions string) error {
+
+	entries := strings.FieldsFunc(extensions, func(r rune) bool { return r == ';' || r == '\n' })
+	subjectAltNameCritical := false
+
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid extension %q, expected name=value", entry)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		critical, values := splitExtensionValues(parts[1])
+
+		var extension *pkix.Extension
+		var err error
+
+		switch strings.ToLower(name) {
+		case "subjectaltname":
+			subjectAltNameCritical = subjectAltNameCritical || critical
+			for _, value := range values {
+				if err = addSubjectAltName(template, value); err != nil {
+					break
+				}
+			}
+		case "keyusage":
+			extension, err = keyUsageExtension(values)
+		case "extendedkeyusage":
+			extension, err = extendedKeyUsageExtension(values)
+		case "basicconstraints":
+			extension, err = basicConstraintsExtension(values)
+		default:
+			extension, err = rawExtension(name, values)
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid extension %q: %s", entry, err)
+		}
+
+		if extension != nil {
+			extension.Critical = extension.Critical || critical
+			setExtension(template, *extension)
+		}
+	}
+
+	//the x509 package only encodes the names of the template as a non critical extension
+	if subjectAltNameCritical {
+		extension, err := subjectAltNameExtension(template)
+		if err != nil {
+			return fmt.Errorf("invalid extension subjectAltName: %s", err)
+		}
+		setExtension(template, *extension)
+	}
+
+	return nil
+}
+
+//splits the comma separated values and removes the leading "critical" flag
+func splitExtensionValues(value string) (bool, []string) {
+
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	if len(values) > 0 && strings.EqualFold(values[0], "critical") {
+		return true, values[1:]
+	}
+	return false, values
+}
+
+//replaces an extension with the same oid
+func setExtension(template *x509.CertificateRequest, extension pkix.Extension) {
+	for i, existing := range template.ExtraExtensions {
+		if existing.Id.Equal(extension.Id) {
+			template.ExtraExtensions[i] = extension
+			return
+		}
+	}
+	template.ExtraExtensions = append(template.ExtraExtensions, extension)
+}
+
+//addSubjectAltName - adds a name with the prefix DNS:, IP:, URI: or email:
+//without a prefix the type is derived from the value
+func addSubjectAltName(template *x509.CertificateRequest, name string) error {
+
+	name = strings.TrimSpace(name)
+	nameType := ""
+
+	if i := strings.Index(name, ":"); i > 0 {
+		switch prefix := strings.ToLower(name[:i]); prefix {
+		case "dns", "ip", "uri", "email":
+			nameType, name = prefix, name[i+1:]
+		}
+	}
+
+	if nameType == "" {
+		switch {
+		case net.ParseIP(name) != nil:
+			nameType = "ip"
+		case strings.Contains(name, "://"):
+			nameType = "uri"
+		case strings.Contains(name, "@"):
+			nameType = "email"
+		default:
+			nameType = "dns"
+		}
+	}
+
+	if name == "" {
+		return fmt.Errorf("empty subject alternative name")
+	}
+
+	switch nameType {
+	case "ip":
+		ip := net.ParseIP(name)
+		if ip == nil {
+			return fmt.Errorf("invalid ip address %q", name)
+		}
+		template.IPAddresses = append(template.IPAddresses, ip)
+	case "uri":
+		uri, err := url.Parse(name)
+		if err != nil || uri.Scheme == "" {
+			return fmt.Errorf("invalid uri %q", name)
+		}
+		template.URIs = append(template.URIs, uri)
+	case "email":
+		template.EmailAddresses = append(template.EmailAddresses, name)
+	default:
+		template.DNSNames = append(template.DNSNames, name)
+	}
+
+	return nil
+}
+
+//the critical extension of the dns names, email addresses, ip addresses and uris of the template
+func subjectAltNameExtension(template *x509.CertificateRequest) (*pkix.Extension, error) {
+
+	var names []asn1.RawValue
+	for _, name := range template.DNSNames {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: generalNameDNS, Bytes: []byte(name)})
+	}
+	for _, email := range template.EmailAddresses {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: generalNameEmail, Bytes: []byte(email)})
+	}
+	for _, ip := range template.IPAddresses {
+		if ipv4 := ip.To4(); ipv4 != nil {
+			ip = ipv4
+		}
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: generalNameIP, Bytes: ip})
+	}
+	for _, uri := range template.URIs {
+		names = append(names, asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: generalNameURI, Bytes: []byte(uri.String())})
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no subject alternative name given")
+	}
+	for _, name := range names {
+		if name.Tag != generalNameIP && !isIA5String(name.Bytes) {
+			return nil, fmt.Errorf("subject alternative name %q is not an IA5 string", name.Bytes)
+		}
+	}
+
+	value, err := asn1.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{Id: oidExtensionSubjectAltName, Critical: true, Value: value}, nil
+}
+
+func isIA5String(value []byte) bool {
+	for _, c := range value {
+		if c >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func keyUsageExtension(values []string) (*pkix.Extension, error) {
+
+	bits := asn1.BitString{Bytes: make([]byte, 2)}
+	for _, value := range values {
+		bit, ok := keyUsages[strings.ToLower(value)]
+		if !ok {
+			return nil, fmt.Errorf("unknown key usage %q", value)
+		}
+		bits.Bytes[bit/8] |= 0x80 >> uint(bit%8)
+		if bit+1 > bits.BitLength {
+			bits.BitLength = bit + 1
+		}
+	}
+
+	if bits.BitLength == 0 {
+		return nil, fmt.Errorf("no key usage given")
+	}
+	bits.Bytes = bits.Bytes[:(bits.BitLength+7)/8]
+
+	value, err := asn1.Marshal(bits)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value}, nil
+}
+
+func extendedKeyUsageExtension(values []string) (*pkix.Extension, error) {
+
+	var usages []asn1.ObjectIdentifier
+	for _, value := range values {
+		usage, ok := extendedKeyUsages[strings.ToLower(value)]
+		if !ok {
+			var err error
+			if usage, err = parseOID(value); err != nil {
+				return nil, fmt.Errorf("unknown extended key usage %q", value)
+			}
+		}
+		usages = append(usages, usage)
+	}
+
+	if len(usages) == 0 {
+		return nil, fmt.Errorf("no extended key usage given")
+	}
+
+	value, err := asn1.Marshal(usages)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{Id: oidExtensionExtendedKeyUsage, Value: value}, nil
+}
+
+//CA:TRUE|FALSE with an optional pathlen:N
+func basicConstraintsExtension(values []string) (*pkix.Extension, error) {
+
+	constraints := basicConstraints{MaxPathLen: -1}
+
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid basic constraint %q", value)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "ca":
+			isCA, err := strconv.ParseBool(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid basic constraint %q", value)
+			}
+			constraints.IsCA = isCA
+		case "pathlen":
+			pathLen, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || pathLen < 0 {
+				return nil, fmt.Errorf("invalid basic constraint %q", value)
+			}
+			constraints.MaxPathLen = pathLen
+		default:
+			return nil, fmt.Errorf("unknown basic constraint %q", value)
+		}
+	}
+
+	value, err := asn1.Marshal(constraints)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{Id: oidExtensionBasicConstraints, Critical: true, Value: value}, nil
+}
+
+//an extension given by its oid with a DER:<hex> value
+func rawExtension(name string, values []string) (*pkix.Extension, error) {
+
+	oid, err := parseOID(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown extension %q", name)
+	}
+
+	if len(values) != 1 || !strings.HasPrefix(strings.ToUpper(values[0]), "DER:") {
+		return nil, fmt.Errorf("expected a single DER:<hex> value")
+	}
+
+	value, err := hex.DecodeString(strings.Replace(values[0][len("DER:"):], ":", "", -1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DER value")
+	}
+	return &pkix.Extension{Id: oid, Value: value}, nil
+}
+
+func parseOID(value string) (asn1.ObjectIdentifier, error) {
+
+	var oid asn1.ObjectIdentifier
+	for _, arc := range strings.Split(value, ".") {
+		n, err := strconv.Atoi(arc)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid oid %q", value)
+		}
+		oid = append(oid, n)
+	}
+
+	if len(oid) < 2 {
+		return nil, fmt.Errorf("invalid oid %q", value)
+	}
+	return oid, nil
+}
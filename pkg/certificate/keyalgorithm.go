@@ -0,0 +1,92 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//supported key algorithms, the values are the ones used by the kyma connector
+const (
+	KeyAlgorithmRSA2048   string = "rsa2048"
+	KeyAlgorithmRSA3072   string = "rsa3072"
+	KeyAlgorithmRSA4096   string = "rsa4096"
+	KeyAlgorithmECDSAP256 string = "ecdsa-p256"
+	KeyAlgorithmECDSAP384 string = "ecdsa-p384"
+)
+
+//ParseKeyAlgorithm - normalizes the key algorithm advertised by the connector
+//accepts e.g. rsa2048, RSA-4096, ec256, ecdsa-p384, P-256, prime256v1 or secp384r1
+func ParseKeyAlgorithm(keyAlgorithm string) (string, error) {
+
+	normalized := strings.ToLower(strings.TrimSpace(keyAlgorithm))
+	normalized = strings.NewReplacer("-", "", "_", "", ":", "", " ", "").Replace(normalized)
+
+	switch normalized {
+	case "ec256", "ecdsa256", "ecp256", "ecdsap256", "p256", "prime256v1", "secp256r1":
+		return KeyAlgorithmECDSAP256, nil
+	case "ec384", "ecdsa384", "ecp384", "ecdsap384", "p384", "secp384r1":
+		return KeyAlgorithmECDSAP384, nil
+	}
+
+	if strings.HasPrefix(normalized, "rsa") {
+		bits, err := strconv.Atoi(strings.TrimPrefix(normalized, "rsa"))
+		if err == nil {
+			switch bits {
+			case 2048:
+				return KeyAlgorithmRSA2048, nil
+			case 3072:
+				return KeyAlgorithmRSA3072, nil
+			case 4096:
+				return KeyAlgorithmRSA4096, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unsupported key algorithm %q", keyAlgorithm)
+}
+
+//generates the private key for the algorithm and returns it with the signature algorithm of the csr and its PEM encoding
+func generateKey(keyAlgorithm string) (crypto.Signer, x509.SignatureAlgorithm, []byte, error) {
+
+	algorithm, err := ParseKeyAlgorithm(keyAlgorithm)
+	if err != nil {
+		return nil, x509.UnknownSignatureAlgorithm, nil, err
+	}
+
+	switch algorithm {
+	case KeyAlgorithmECDSAP256, KeyAlgorithmECDSAP384:
+		curve, signatureAlgorithm := elliptic.P256(), x509.ECDSAWithSHA256
+		if algorithm == KeyAlgorithmECDSAP384 {
+			curve, signatureAlgorithm = elliptic.P384(), x509.ECDSAWithSHA384
+		}
+
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, x509.UnknownSignatureAlgorithm, nil, err
+		}
+
+		keyBytes, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, x509.UnknownSignatureAlgorithm, nil, err
+		}
+
+		return key, signatureAlgorithm, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), nil
+	}
+
+	bits, _ := strconv.Atoi(strings.TrimPrefix(algorithm, "rsa"))
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, x509.UnknownSignatureAlgorithm, nil, err
+	}
+
+	return key, x509.SHA256WithRSA, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), nil
+}
@@ -0,0 +1,102 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestParseKeyAlgorithm(t *testing.T) {
+
+	tests := []struct {
+		keyAlgorithm string
+		expected     string
+	}{
+		{"rsa2048", KeyAlgorithmRSA2048},
+		{"RSA-3072", KeyAlgorithmRSA3072},
+		{" rsa_4096 ", KeyAlgorithmRSA4096},
+		{"ec256", KeyAlgorithmECDSAP256},
+		{"ECDSA-P256", KeyAlgorithmECDSAP256},
+		{"P-256", KeyAlgorithmECDSAP256},
+		{"prime256v1", KeyAlgorithmECDSAP256},
+		{"secp384r1", KeyAlgorithmECDSAP384},
+		{"ecdsa-p384", KeyAlgorithmECDSAP384},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseKeyAlgorithm(test.keyAlgorithm)
+		if err != nil {
+			t.Errorf("ParseKeyAlgorithm(%q): %s", test.keyAlgorithm, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("ParseKeyAlgorithm(%q) = %s, want %s", test.keyAlgorithm, actual, test.expected)
+		}
+	}
+
+	for _, keyAlgorithm := range []string{"", "rsa", "rsa1024", "rsa-abc", "ec521", "ed25519", "dsa2048"} {
+		if actual, err := ParseKeyAlgorithm(keyAlgorithm); err == nil {
+			t.Errorf("ParseKeyAlgorithm(%q) = %s, want an error", keyAlgorithm, actual)
+		}
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+
+	tests := []struct {
+		keyAlgorithm       string
+		signatureAlgorithm x509.SignatureAlgorithm
+		pemType            string
+		check              func(t *testing.T, der []byte)
+	}{
+		{"rsa2048", x509.SHA256WithRSA, "RSA PRIVATE KEY", func(t *testing.T, der []byte) {
+			key, err := x509.ParsePKCS1PrivateKey(der)
+			if err != nil || key.N.BitLen() != 2048 {
+				t.Errorf("the key is not an rsa 2048 key: %v", err)
+			}
+		}},
+		{"ec256", x509.ECDSAWithSHA256, "EC PRIVATE KEY", func(t *testing.T, der []byte) {
+			key, err := x509.ParseECPrivateKey(der)
+			if err != nil || key.Curve != elliptic.P256() {
+				t.Errorf("the key is not a P-256 key: %v", err)
+			}
+		}},
+		{"ecdsa-p384", x509.ECDSAWithSHA384, "EC PRIVATE KEY", func(t *testing.T, der []byte) {
+			key, err := x509.ParseECPrivateKey(der)
+			if err != nil || key.Curve != elliptic.P384() {
+				t.Errorf("the key is not a P-384 key: %v", err)
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.keyAlgorithm, func(t *testing.T) {
+			signer, signatureAlgorithm, encoded, err := generateKey(test.keyAlgorithm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signatureAlgorithm != test.signatureAlgorithm {
+				t.Errorf("signature algorithm = %s, want %s", signatureAlgorithm, test.signatureAlgorithm)
+			}
+
+			switch signer.(type) {
+			case *rsa.PrivateKey, *ecdsa.PrivateKey:
+			default:
+				t.Errorf("unexpected key %T", signer)
+			}
+
+			block, _ := pem.Decode(encoded)
+			if block == nil || block.Type != test.pemType {
+				t.Fatalf("the key is not PEM encoded as %s: %s", test.pemType, encoded)
+			}
+			test.check(t, block.Bytes)
+		})
+	}
+
+	if _, _, _, err := generateKey("rsa1024"); err == nil {
+		t.Error("generateKey accepted rsa1024")
+	}
+}
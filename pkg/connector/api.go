@@ -1,17 +1,25 @@
 package connector
 
 import (
+	"bytes"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	cert "github.com/jcawley/kyma-app-connector/pkg/certificate"
+	"github.com/jcawley/kyma-app-connector/pkg/store"
 	"github.com/jcawley/kyma-app-connector/pkg/utils"
 )
 
@@ -22,33 +30,79 @@ type kymaTLSClient interface {
 //Connector -
 type Connector interface {
 	callTokenURL(string) ([]byte, error)
-	sendCSRToKyma([]byte) ([]byte, error)
-	getAppInfo(*http.Client) ([]byte, error)
+	sendCSRToKyma([]byte) (*csrConnectResponse, error)
+	getAppInfo(*http.Client, PackageConfig) ([]byte, error)
 	sendAPISpec(*http.Client, []byte, []byte) ([]byte, error)
 	sendEventSpec(*http.Client, []byte) ([]byte, error)
+	updateAPISpec(*http.Client, string, []byte, []byte) ([]byte, error)
+	updateEventSpec(*http.Client, string, []byte) ([]byte, error)
+	deleteAPISpec(*http.Client, string) error
+	deleteEventSpec(*http.Client, string) error
+	saveSpec(*http.Client, string, SpecDeclaration, string) (string, error)
+	getRegistrations() []Registration
+	listRegistrations(*http.Client) ([]RegistrationDetails, error)
+	renewCertificate(*http.Client, []byte) (*csrConnectResponse, error)
+	revokeCertificate(*http.Client) error
+	unregisterServices(*http.Client) error
 	getCertificateSubject() string
+	getKeyAlgorithm() string
+	getCertificateExtensions() string
 	getEventURL() string
 }
 
+//the methods expect mu to be held, the entry points lock it for the whole operation so requests and
+//the expiry monitor never see a half renewed or disconnected connection
 type apiConfig struct {
-	AssetsDir        string
+	mu               sync.RWMutex
+	Name             string
 	kc               Connector
 	HTTPTLSClient    *http.Client
 	ConnectionType   string
 	ConnectionStatus string
-	KeyLength        int
+	KeyAlgorithm     string
+	SubjectAltNames  []string
+	EventMode        string
+	EventURL         string
+	keyPair          *tls.Certificate
+	keyPairMu        sync.RWMutex
+	certObtained     time.Time
+	certStatus       certificateStatus
+	monitorStop      chan struct{}
+	monitorWake      chan struct{}
+	certMu           sync.Mutex
 }
 
-var config *apiConfig
-
 const appTypeRest string = "REST"
 const appTypeGraphQL string = "GraphQL"
 const notConnected string = "Not Connected"
 const isConnected string = "Connected"
 
-//GetConnectionStatus -
-func GetConnectionStatus() string {
+//KeyAlgorithmEnv - overrides the key algorithm advertised by the connector for all connections, e.g. ecdsa-p256
+const KeyAlgorithmEnv string = "APP_CONN_KEY_ALGORITHM"
+
+//SubjectAltNamesEnv - comma separated subject alternative names added to the csr of all connections, e.g. DNS:app.example.com,IP:10.0.0.1
+const SubjectAltNamesEnv string = "APP_CONN_CERT_SANS"
+
+var errNoConnection = errors.New("No connection has been established")
+var errNoTLSConnection = errors.New("No TLS Connection established")
+
+//files written to the kymacerts directory during the connection process
+var certFiles = []string{"cert.csr", "private.key", "crtChain.crt", "ca.crt", snapshotFile}
+
+//GetConnectionStatus - the connection status followed by the state of the client certificate
+func GetConnectionStatus(name string) string {
 
+	config := getConnection(name)
+	if config == nil {
+		return notConnected
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	if certStatus := config.certificateStatus().String(); certStatus != "" && config.ConnectionStatus == isConnected {
+		return config.ConnectionStatus + ", certificate " + certStatus
+	}
 	return config.ConnectionStatus
 
 }
@@ -56,26 +110,60 @@ func GetConnectionStatus() string {
 func init() {
 	log.Println("init api called")
 
-	config = &apiConfig{}
-	config.ConnectionStatus = notConnected
-	config.setAssetsDir()
+	setAssetsDir()
+	initTrust()
+	initRenewal()
 }
 
 //will generate a rest or graphql connection based on the tokenData
-func initConnectionType(appType string) {
+func (config *apiConfig) initConnectionType(appType string) {
 
 	if appType == appTypeGraphQL {
 		config.kc = &graphQLConnector{}
 		config.ConnectionType = appTypeGraphQL
-		config.KeyLength = 4096
 	} else {
 		config.kc = &restConnector{}
 		config.ConnectionType = appTypeRest
-		config.KeyLength = 2048
 	}
 	log.Printf("Initialized connection of type: %s", config.ConnectionType)
 }
 
+//the key algorithm for the csr: the override of the connection or from the environment, else the one advertised by the connector
+func (config *apiConfig) keyAlgorithm() string {
+
+	if config.KeyAlgorithm != "" {
+		return config.KeyAlgorithm
+	}
+
+	if keyAlgorithm := os.Getenv(KeyAlgorithmEnv); keyAlgorithm != "" {
+		return keyAlgorithm
+	}
+
+	if keyAlgorithm := config.kc.getKeyAlgorithm(); keyAlgorithm != "" {
+		return keyAlgorithm
+	}
+
+	//older connectors do not advertise an algorithm
+	if config.ConnectionType == appTypeGraphQL {
+		return cert.KeyAlgorithmRSA4096
+	}
+	return cert.KeyAlgorithmRSA2048
+}
+
+//the extensions advertised by the connector and the subject alternative names of the connection or from the environment
+func (config *apiConfig) csrOptions() cert.CSROptions {
+
+	subjectAltNames := config.SubjectAltNames
+	if len(subjectAltNames) == 0 && os.Getenv(SubjectAltNamesEnv) != "" {
+		subjectAltNames = strings.Split(os.Getenv(SubjectAltNamesEnv), ",")
+	}
+
+	return cert.CSROptions{
+		Extensions:      config.kc.getCertificateExtensions(),
+		SubjectAltNames: subjectAltNames,
+	}
+}
+
 func getConnTypeByTokenData(tokenData string) string {
 	var appType string
 	_, err := base64.StdEncoding.DecodeString(tokenData)
@@ -98,219 +186,555 @@ func CallTokenURL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenDataStr := string(tokenData)
-
-	connType := getConnTypeByTokenData(tokenDataStr)
-	initConnectionType(connType)
+	config := getOrCreateConnection(ConnectionName(r))
 
-	resp, err := config.kc.callTokenURL(tokenDataStr)
+	config.mu.Lock()
+	resp, err := config.callTokenURL(string(tokenData))
+	config.mu.Unlock()
 
-	if err != nil {
-		utils.ReturnError(err.Error(), w)
-	} else {
-		utils.ReturnSuccess(string(resp), w)
-	}
+	returnResult(resp, err, w)
 }
 
 //CreateSecureConnection - STEP 2
 func CreateSecureConnection(w http.ResponseWriter, r *http.Request) {
 	log.Println("CreateSecureConnection")
 
-	if config.kc == nil {
-		utils.ReturnError("No connection has been established", w)
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoConnection.Error(), w)
 		return
 	}
 
-	subject := config.kc.getCertificateSubject()
+	config.mu.Lock()
+	resp, err := config.createSecureConnection()
+	config.mu.Unlock()
 
-	if subject == "" {
-		utils.ReturnError("No Certificate Subject found", w)
+	returnResult(resp, err, w)
+}
+
+//GetAppInfo - STEP 3, the body may configure the package created for a compass application
+//?hostURL= is the target url relative token urls of the package are resolved against
+func GetAppInfo(w http.ResponseWriter, r *http.Request) {
+	log.Println("GetAppInfo")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
 		return
 	}
 
-	KymaCerts, err := cert.GenerateCSR(subject, config.KeyLength)
-
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		utils.ReturnError("Could not generate the CSR", w)
+		utils.ReturnError("Could not read the body text", w)
 		return
 	}
-	ioutil.WriteFile(config.AssetsDir+"/kymacerts/cert.csr", KymaCerts.CSR, 0644)
 
-	crtChain, err := config.kc.sendCSRToKyma(KymaCerts.CSR)
-
-	if err != nil {
-		utils.ReturnError(err.Error(), w)
-	} else {
-		config.saveTLSCerts(crtChain, KymaCerts.PrivateKey)
-		err := config.setTLSClient()
-		if err != nil {
-			utils.ReturnError("Could not establish a Secure TLS Connection", w)
-		} else {
-			utils.ReturnSuccess("Secure TLS Connection has been established", w)
+	var pkg *PackageConfig
+	if len(bytes.TrimSpace(body)) > 0 {
+		pkg = &PackageConfig{}
+		if err := json.Unmarshal(body, pkg); err != nil {
+			utils.ReturnError("Could not parse the package config: "+err.Error(), w)
+			return
 		}
-
 	}
+
+	config.mu.Lock()
+	resp, err := config.getAppInfo(pkg, []byte(r.URL.Query().Get("hostURL")))
+	config.mu.Unlock()
+
+	returnResult(resp, err, w)
 }
 
-//GetAppInfo - STEP 3
-func GetAppInfo(w http.ResponseWriter, r *http.Request) {
-	log.Println("GetAppInfo")
+//SendAPISpec - STEP 4, a json or multipart body registers a custom spec, any other body is the hostURL of the sample spec
+//an invalid spec is refused unless ?force=true
+func SendAPISpec(w http.ResponseWriter, r *http.Request) {
+	log.Println("SendAPISpec")
 
-	if config.HTTPTLSClient == nil {
-		utils.ReturnError("No TLS Connection established", w)
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
 		return
 	}
 
-	resp, err := config.kc.getAppInfo(config.HTTPTLSClient)
-
+	spec, hostURL, err := readUpload(r, RegistrationKindAPI)
 	if err != nil {
 		utils.ReturnError(err.Error(), w)
-	} else {
-		utils.ReturnSuccess(string(resp), w)
-	}
-}
-
-//SendAPISpec - STEP 4
-func SendAPISpec(w http.ResponseWriter, r *http.Request) {
-	log.Println("SendAPISpec")
-
-	if config.HTTPTLSClient == nil {
-		utils.ReturnError("No TLS Connection established", w)
 		return
 	}
 
-	var APISpec []byte
-	var err error
-	if config.ConnectionType == appTypeRest {
-		APISpec, err = ioutil.ReadFile(config.AssetsDir + "/spec-docs/api-rest.json")
+	config.mu.Lock()
+	var resp []byte
+	if spec != nil {
+		resp, err = config.uploadSpec(*spec, forced(r))
 	} else {
-		APISpec, err = ioutil.ReadFile(config.AssetsDir + "/spec-docs/api-graphql.yaml")
+		resp, err = config.sendAPISpec(hostURL, forced(r))
 	}
+	config.mu.Unlock()
 
-	defer r.Body.Close()
-	hostURL, err := ioutil.ReadAll(r.Body)
+	returnResult(resp, err, w)
+}
 
-	if err != nil || len(hostURL) == 0 {
-		log.Println("No hostURL provided... Setting to http://localhost:8000")
-		hostURL = []byte("http://localhost:8000")
-	}
+//SendEventSpec - STEP 5, a json or multipart body registers a custom spec, else the sample spec is sent
+//an invalid spec is refused unless ?force=true
+func SendEventSpec(w http.ResponseWriter, r *http.Request) {
+	log.Println("SendEventSpec")
 
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	}
+	config := getConnection(ConnectionName(r))
 
-	resp, err := config.kc.sendAPISpec(config.HTTPTLSClient, APISpec, hostURL)
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
+		return
+	}
 
+	spec, _, err := readUpload(r, RegistrationKindEvents)
 	if err != nil {
 		utils.ReturnError(err.Error(), w)
+		return
+	}
+
+	config.mu.Lock()
+	var resp []byte
+	if spec != nil {
+		resp, err = config.uploadSpec(*spec, forced(r))
 	} else {
-		utils.ReturnSuccess(string(resp), w)
+		resp, err = config.sendEventSpec(forced(r))
 	}
+	config.mu.Unlock()
 
+	returnResult(resp, err, w)
 }
 
-//SendEventSpec - STEP 5
-func SendEventSpec(w http.ResponseWriter, r *http.Request) {
-	log.Println("SendEventSpec")
+//RenewCertificate - requests a new client certificate using the current one
+func RenewCertificate(w http.ResponseWriter, r *http.Request) {
+	log.Println("RenewCertificate")
 
-	if config.HTTPTLSClient == nil {
-		utils.ReturnError("No TLS Connection established", w)
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
 		return
 	}
 
-	var EventSpec []byte
-	var err error
-	if config.ConnectionType == appTypeRest {
-		EventSpec, err = ioutil.ReadFile(config.AssetsDir + "/spec-docs/event-rest.json")
-	} else {
-		EventSpec, err = ioutil.ReadFile(config.AssetsDir + "/spec-docs/event-graphql.yaml")
+	config.mu.Lock()
+	err := config.renewCertificate()
+	if err == nil {
+		config.persist()
 	}
+	config.mu.Unlock()
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnSuccess("Certificate has been renewed", w)
 	}
+}
 
-	resp, err := config.kc.sendEventSpec(config.HTTPTLSClient, EventSpec)
+//Disconnect - unregisters the services, revokes the certificate and removes all connection data
+func Disconnect(w http.ResponseWriter, r *http.Request) {
+	log.Println("Disconnect")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoConnection.Error(), w)
+		return
+	}
+
+	config.mu.Lock()
+	err := config.disconnect()
+	config.mu.Unlock()
+	removeConnection(config.Name)
 
 	if err != nil {
 		utils.ReturnError(err.Error(), w)
 	} else {
-		utils.ReturnSuccess(string(resp), w)
+		utils.ReturnSuccess("Connection has been removed", w)
 	}
 }
 
 //GetAssetsDir -
 func GetAssetsDir() string {
-	return config.AssetsDir
+	return assetsDir
 }
 
 //GetEventURL -
-func GetEventURL() string {
+func GetEventURL(name string) string {
+	config := getConnection(name)
+	if config == nil {
+		return ""
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	if config.kc == nil {
+		return ""
+	}
 	return config.kc.getEventURL()
 }
 
 //GetHTTPTLSClient -
-func GetHTTPTLSClient() *http.Client {
+func GetHTTPTLSClient(name string) *http.Client {
+	config := getConnection(name)
+	if config == nil {
+		return nil
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
 	return config.HTTPTLSClient
 }
 
 //determines directory location
-func (config *apiConfig) setAssetsDir() {
+func setAssetsDir() {
 	_, filename, _, ok := runtime.Caller(0)
 	if !ok {
 		panic("No caller information")
 	}
 
-	config.AssetsDir = filepath.Join(path.Dir(filename), "../../assets")
+	assetsDir = filepath.Join(path.Dir(filename), "../../assets")
 }
 
-//saves the TLS certs for later use
-func (config *apiConfig) saveTLSCerts(crtChain []byte, privateKey []byte) {
+//writes a certificate, key or the connection state to the credential store
+func (config *apiConfig) saveCredential(name string, data []byte) error {
+	return credentialStore.Save(config.Name, name, data)
+}
+
+func (config *apiConfig) loadCredential(name string) ([]byte, error) {
+	return credentialStore.Load(config.Name, name)
+}
+
+//initializes the connection type based on the tokenData and calls the token url
+func (config *apiConfig) callTokenURL(tokenData string) ([]byte, error) {
+
+	connType := getConnTypeByTokenData(tokenData)
+	config.initConnectionType(connType)
+
+	return config.kc.callTokenURL(tokenData)
+}
+
+//generates a csr based on the certificate subject and exchanges it for the client certificate
+func (config *apiConfig) createSecureConnection() ([]byte, error) {
+
+	if config.kc == nil {
+		return nil, errNoConnection
+	}
+
+	subject := config.kc.getCertificateSubject()
+
+	if subject == "" {
+		return nil, errors.New("No Certificate Subject found")
+	}
+
+	KymaCerts, err := cert.GenerateCSR(subject, config.keyAlgorithm(), config.csrOptions())
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate the CSR: %s", err)
+	}
+	config.saveCredential("cert.csr", KymaCerts.CSR)
+
+	signedCerts, err := config.kc.sendCSRToKyma(KymaCerts.CSR)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.saveTLSCerts(signedCerts, KymaCerts.PrivateKey)
+	if err == nil {
+		//a new connection may trust different CAs, so the client is rebuilt
+		config.HTTPTLSClient = nil
+		err = config.setTLSClient()
+	}
+	if err != nil {
+		return nil, errors.New("Could not establish a Secure TLS Connection")
+	}
+
+	config.persist()
+	return []byte("Secure TLS Connection has been established"), nil
+}
+
+//the package config completes the requested one with the config file and defaults
+func (config *apiConfig) getAppInfo(requested *PackageConfig, hostURL []byte) ([]byte, error) {
 
-	ioutil.WriteFile(config.AssetsDir+"/kymacerts/private.key", privateKey, 0644)
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	pkg, err := packageConfig(requested, string(defaultHostURL(hostURL)))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := config.kc.getAppInfo(config.HTTPTLSClient, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	config.persist()
+	return resp, nil
+}
+
+//sends the sample api spec, the hostURL is registered as the target url of the api
+func (config *apiConfig) sendAPISpec(hostURL []byte, force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	APISpec, err := config.sampleAPISpec()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.registerAPISpec(APISpec, hostURL, force)
+}
+
+//registers the given api spec, a rest envelope or an open api document depending on the connection type
+//an invalid spec is refused unless forced
+func (config *apiConfig) registerAPISpec(APISpec []byte, hostURL []byte, force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	hostURL = defaultHostURL(hostURL)
+	if err := config.checkAPISpec(APISpec, hostURL, force); err != nil {
+		return nil, err
+	}
+
+	resp, err := config.kc.sendAPISpec(config.HTTPTLSClient, APISpec, hostURL)
+	if err != nil {
+		return nil, err
+	}
+
+	config.persist()
+	return resp, nil
+}
+
+//the target url registered if none is given
+func defaultHostURL(hostURL []byte) []byte {
+	if len(hostURL) == 0 {
+		log.Println("No hostURL provided... Setting to http://localhost:8000")
+		return []byte("http://localhost:8000")
+	}
+	return hostURL
+}
+
+func (config *apiConfig) sendEventSpec(force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	EventSpec, err := config.sampleEventSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.registerEventSpec(EventSpec, force)
+}
+
+//the sample event spec matching the connection type
+func (config *apiConfig) sampleEventSpec() ([]byte, error) {
+	if config.ConnectionType == appTypeRest {
+		return ioutil.ReadFile(assetsDir + "/spec-docs/event-rest.json")
+	}
+	return ioutil.ReadFile(assetsDir + "/spec-docs/event-graphql.yaml")
+}
+
+//registers the given event spec, a rest envelope or an async api document depending on the connection type
+//an invalid spec is refused unless forced
+func (config *apiConfig) registerEventSpec(EventSpec []byte, force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	if err := config.checkSpec(RegistrationKindEvents, EventSpec, force); err != nil {
+		return nil, err
+	}
+
+	resp, err := config.kc.sendEventSpec(config.HTTPTLSClient, EventSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	config.persist()
+	return resp, nil
+}
+
+//generates a new csr, has it signed using the current client certificate and swaps in the new chain
+func (config *apiConfig) renewCertificate() error {
+
+	if config.kc == nil || config.HTTPTLSClient == nil {
+		return errNoTLSConnection
+	}
+
+	subject := config.kc.getCertificateSubject()
+
+	if subject == "" {
+		return errors.New("No Certificate Subject found")
+	}
+
+	KymaCerts, err := cert.GenerateCSR(subject, config.keyAlgorithm(), config.csrOptions())
 
-	decodedCrtChain, decodeErr := base64.StdEncoding.DecodeString(string(crtChain))
+	if err != nil {
+		return fmt.Errorf("Could not generate the CSR: %s", err)
+	}
+
+	signedCerts, err := config.kc.renewCertificate(config.HTTPTLSClient, KymaCerts.CSR)
+	if err != nil {
+		return err
+	}
+
+	config.saveCredential("cert.csr", KymaCerts.CSR)
+
+	err = config.saveTLSCerts(signedCerts, KymaCerts.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	return config.setTLSClient()
+}
+
+//tears down the connection, the local state is always reset even if the system could not be reached
+func (config *apiConfig) disconnect() error {
+
+	config.stopMonitor()
+
+	var errMsgs []string
+
+	if config.kc != nil && config.HTTPTLSClient != nil {
+		if err := config.kc.unregisterServices(config.HTTPTLSClient); err != nil {
+			log.Printf("disconnect: could not unregister services: %s", err)
+			errMsgs = append(errMsgs, "could not unregister services: "+err.Error())
+		}
+
+		if err := config.kc.revokeCertificate(config.HTTPTLSClient); err != nil {
+			log.Printf("disconnect: could not revoke certificate: %s", err)
+			errMsgs = append(errMsgs, "could not revoke certificate: "+err.Error())
+		}
+	}
+
+	for _, certFile := range certFiles {
+		err := credentialStore.Delete(config.Name, certFile)
+		if err != nil && err != store.ErrNotFound {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+
+	if config.HTTPTLSClient != nil {
+		if transport, ok := config.HTTPTLSClient.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+	}
+
+	config.keyPairMu.Lock()
+	config.keyPair = nil
+	config.keyPairMu.Unlock()
+
+	config.kc = nil
+	config.HTTPTLSClient = nil
+	config.ConnectionType = ""
+	config.ConnectionStatus = notConnected
+
+	if len(errMsgs) > 0 {
+		return fmt.Errorf("Connection has been removed locally, but: %s", strings.Join(errMsgs, "; "))
+	}
+	return nil
+}
+
+//saves the TLS certs for later use
+func (config *apiConfig) saveTLSCerts(signedCerts *csrConnectResponse, privateKey []byte) error {
+
+	decodedCrtChain, decodeErr := base64.StdEncoding.DecodeString(signedCerts.Crt)
 	if decodeErr != nil {
-		log.Fatalf("something went wrong decoding the crtChain")
+		return errors.New("something went wrong decoding the crtChain")
 	}
 	crtDecodedBytes := []byte(string(decodedCrtChain))
 
-	ioutil.WriteFile(config.AssetsDir+"/kymacerts/crtChain.crt", crtDecodedBytes, 0644)
+	decodedCaCrt, decodeErr := base64.StdEncoding.DecodeString(signedCerts.CaCrt)
+	if decodeErr != nil {
+		return errors.New("something went wrong decoding the caCrt")
+	}
 
+	//make sure the chain matches the key before replacing the current files
+	if _, err := tls.X509KeyPair(crtDecodedBytes, privateKey); err != nil {
+		return err
+	}
+
+	if err := config.saveCredential("private.key", privateKey); err != nil {
+		return err
+	}
+	if err := config.saveCredential("crtChain.crt", crtDecodedBytes); err != nil {
+		return err
+	}
+
+	if len(decodedCaCrt) > 0 {
+		if err := config.saveCredential("ca.crt", decodedCaCrt); err != nil {
+			return err
+		}
+	}
+
+	config.certObtained = time.Now()
+	return nil
 }
 
 //Creates the TLS connection that all communication between the system will use
+//if a client already exists only its certificate is swapped, so requests in flight are not interrupted
 func (config *apiConfig) setTLSClient() error {
 
 	log.Println("setTLSClient....")
-	log.Println(config.AssetsDir)
-	config.ConnectionStatus = notConnected
 
-	keyPair, err := tls.LoadX509KeyPair(config.AssetsDir+"/kymacerts/crtChain.crt", config.AssetsDir+"/kymacerts/private.key")
-	if err != nil {
-		log.Println("setTLSClient: no keypair exists")
-		return err
+	crtChain, err := config.loadCredential("crtChain.crt")
+	var privateKey []byte
+	if err == nil {
+		privateKey, err = config.loadCredential("private.key")
 	}
 
-	crtChain, err := ioutil.ReadFile(config.AssetsDir + "/kymacerts/crtChain.crt")
+	var keyPair tls.Certificate
+	if err == nil {
+		keyPair, err = tls.X509KeyPair(crtChain, privateKey)
+	}
 	if err != nil {
-		log.Println("setTLSClient: no clientCrt exists")
+		log.Println("setTLSClient: no keypair exists")
+		config.ConnectionStatus = notConnected
 		return err
 	}
 
-	caCertPool, _ := x509.SystemCertPool()
-	if caCertPool == nil {
-		caCertPool = x509.NewCertPool()
+	config.keyPairMu.Lock()
+	config.keyPair = &keyPair
+	config.keyPairMu.Unlock()
+
+	config.recordCertificate(&keyPair, config.certObtained)
+
+	if config.HTTPTLSClient != nil {
+		//idle connections still hold the previous certificate, new ones will use the current
+		if transport, ok := config.HTTPTLSClient.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+		log.Println("TLSClient certificate has been replaced...")
+		config.ConnectionStatus = isConnected
+		config.startMonitor()
+		return nil
 	}
 
-	caCertPool.AppendCertsFromPEM(crtChain)
+	caCertPool, err := config.rootCAs()
+	if err != nil {
+		config.ConnectionStatus = notConnected
+		return err
+	}
 
 	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		Certificates:       []tls.Certificate{keyPair},
-		RootCAs:            caCertPool,
+		InsecureSkipVerify:   trust.insecureSkipVerify,
+		GetClientCertificate: config.getClientCertificate,
+		RootCAs:              caCertPool,
 	}
-	tlsConfig.BuildNameToCertificate()
 
 	config.HTTPTLSClient = &http.Client{
 		Transport: &http.Transport{
@@ -320,5 +744,17 @@ func (config *apiConfig) setTLSClient() error {
 
 	log.Println("TLSClient has been set...")
 	config.ConnectionStatus = isConnected
+	config.startMonitor()
 	return nil
 }
+
+//provides the current client certificate during the tls handshake
+func (config *apiConfig) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	config.keyPairMu.RLock()
+	defer config.keyPairMu.RUnlock()
+
+	if config.keyPair == nil {
+		return &tls.Certificate{}, nil
+	}
+	return config.keyPair, nil
+}
@@ -0,0 +1,98 @@
+package connector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/tidwall/sjson"
+)
+
+//APISpecSourceEnv - static registers the sample api spec of assets/spec-docs instead of the generated one
+const APISpecSourceEnv string = "APP_CONN_API_SPEC_SOURCE"
+
+const apiSpecStatic string = "static"
+
+var apiSpecGenerator func() ([]byte, error)
+
+var sampleAuth func() *AuthConfig
+
+var authRegistered func(AuthConfig)
+
+//SetAPISpecGenerator - sets the generator of the open api document registered as the sample api spec
+func SetAPISpecGenerator(generator func() ([]byte, error)) {
+	apiSpecGenerator = generator
+}
+
+//SetSampleAuth - sets the credentials the api of the sample api spec expects, nil if it is not protected
+//they are registered with the sample service of the kyma connector, compass packages only get a configured auth
+func SetSampleAuth(auth func() *AuthConfig) {
+	sampleAuth = auth
+}
+
+//SetAuthRegistered - sets the function called with the default instance auth of a created compass package,
+//the api the package describes can then expect the credentials the gateway was given
+func SetAuthRegistered(registered func(AuthConfig)) {
+	authRegistered = registered
+}
+
+//the sample api spec matching the connection type, the generated open api document replaces the one of the
+//static file if a generator is set
+func (config *apiConfig) sampleAPISpec() ([]byte, error) {
+
+	var document []byte
+	if apiSpecGenerator != nil && os.Getenv(APISpecSourceEnv) != apiSpecStatic {
+		var err error
+		if document, err = apiSpecGenerator(); err != nil {
+			return nil, err
+		}
+		log.Println("Using the generated api spec")
+	}
+
+	if config.ConnectionType != appTypeRest {
+		if document != nil {
+			return document, nil
+		}
+		return ioutil.ReadFile(assetsDir + "/spec-docs/api-graphql.yaml")
+	}
+
+	service, err := ioutil.ReadFile(assetsDir + "/spec-docs/api-rest.json")
+	if err != nil {
+		return nil, err
+	}
+
+	//the static service provides the name, labels and request parameters
+	if document != nil {
+		if service, err = sjson.SetRawBytes(service, "api.spec", document); err != nil {
+			return nil, err
+		}
+	}
+
+	//the credentials of the file are replaced by the ones the api expects
+	if sampleAuth == nil {
+		return service, nil
+	}
+	if service, err = sjson.DeleteBytes(service, "api.credentials"); err != nil {
+		return nil, err
+	}
+
+	auth := sampleAuth()
+	if auth == nil {
+		return service, nil
+	}
+
+	credentials, requestParameters, err := auth.restAuth()
+	if err != nil {
+		return nil, fmt.Errorf("credentials of the sample api: %s", err)
+	}
+	if credentials != nil {
+		if service, err = sjson.SetBytes(service, "api.credentials", credentials); err != nil {
+			return nil, err
+		}
+	}
+	if requestParameters != nil {
+		service, err = sjson.SetBytes(service, "api.requestParameters", requestParameters)
+	}
+	return service, err
+}
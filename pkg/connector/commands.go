@@ -0,0 +1,151 @@
+package connector
+
+import "fmt"
+
+//GetConnection - returns the summary of the named connection
+func GetConnection(name string) (ConnectionInfo, error) {
+	config := getConnection(name)
+	if config == nil {
+		return ConnectionInfo{}, fmt.Errorf("connection %s does not exist", name)
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	return config.info(), nil
+}
+
+//RenewConnection - renews the client certificate of the named connection
+func RenewConnection(name string) error {
+	config := getConnection(name)
+	if config == nil {
+		return errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if err := config.renewCertificate(); err != nil {
+		return err
+	}
+
+	config.persist()
+	return nil
+}
+
+//RegisterAPISpec - registers an api spec for the named connection, the sample spec is used if none is given
+//an invalid spec is refused unless forced
+func RegisterAPISpec(name string, apiSpec []byte, hostURL string, force bool) ([]byte, error) {
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if apiSpec == nil {
+		return config.sendAPISpec([]byte(hostURL), force)
+	}
+	return config.registerAPISpec(apiSpec, []byte(hostURL), force)
+}
+
+//RegisterEventSpec - registers an event spec for the named connection, the sample spec is used if none is given
+//an invalid spec is refused unless forced
+func RegisterEventSpec(name string, eventSpec []byte, force bool) ([]byte, error) {
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if eventSpec == nil {
+		return config.sendEventSpec(force)
+	}
+	return config.registerEventSpec(eventSpec, force)
+}
+
+//UploadSpec - registers a custom api or event spec for the named connection, an invalid spec is refused unless forced
+func UploadSpec(name string, spec SpecDeclaration, force bool) ([]byte, error) {
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	return config.uploadSpec(spec, force)
+}
+
+//GetRegistrations - lists the apis and events the system has registered for the named connection
+func GetRegistrations(name string) ([]RegistrationDetails, error) {
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	return config.listRegistrations()
+}
+
+//UpdateRegisteredAPISpec - replaces the api registered with the id for the named connection, the sample spec is used if none is given
+func UpdateRegisteredAPISpec(name string, id string, apiSpec []byte, hostURL string, force bool) ([]byte, error) {
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	return config.updateAPISpec(id, apiSpec, []byte(hostURL), force)
+}
+
+//UpdateRegisteredEventSpec - replaces the events registered with the id for the named connection, the sample spec is used if none is given
+func UpdateRegisteredEventSpec(name string, id string, eventSpec []byte, force bool) ([]byte, error) {
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	return config.updateEventSpec(id, eventSpec, force)
+}
+
+//DeleteRegistration - removes the api or events registered with the id for the named connection
+func DeleteRegistration(name string, kind string, id string) error {
+	config := getConnection(name)
+	if config == nil {
+		return errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if kind == RegistrationKindEvents {
+		return config.deleteEventSpec(id)
+	}
+	return config.deleteAPISpec(id)
+}
+
+//DisconnectConnection - tears down the named connection
+func DisconnectConnection(name string) error {
+	config := getConnection(name)
+	if config == nil {
+		return errNoConnection
+	}
+
+	config.mu.Lock()
+	err := config.disconnect()
+	config.mu.Unlock()
+
+	removeConnection(name)
+	return err
+}
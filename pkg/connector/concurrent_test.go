@@ -0,0 +1,155 @@
+package connector_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+const concurrentRuns int = 5
+
+func newTestRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/connections", connector.ListConnections)
+	router.HandleFunc("/connections/{name}/renewCertificate", connector.RenewCertificate)
+	router.HandleFunc("/connections/{name}/eventMode", connector.SetEventMode).Methods("POST")
+	return router
+}
+
+//serves the request and fails the test unless it succeeds
+func serveTestRequest(t *testing.T, router *mux.Router, method string, path string, body string) {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("%s %s = %d: %s", method, path, rec.Code, rec.Body.String())
+	}
+}
+
+//runs every function concurrently, concurrentRuns times each
+func runConcurrently(functions ...func()) {
+	var wg sync.WaitGroup
+	for _, function := range functions {
+		for i := 0; i < concurrentRuns; i++ {
+			wg.Add(1)
+			go func(function func()) {
+				defer wg.Done()
+				function()
+			}(function)
+		}
+	}
+	wg.Wait()
+}
+
+//run with -race, the renewal replaces the client, certificate and status the handlers read
+func TestRenewalWhileHandlersRun(t *testing.T) {
+
+	system := newKymaSystem(t)
+	defer system.close()
+
+	newTestStore()
+	connectTestSystem(t, "concurrent", system)
+
+	router := newTestRouter()
+
+	runConcurrently(
+		func() {
+			if err := connector.RenewConnection("concurrent"); err != nil {
+				t.Errorf("RenewConnection: %s", err)
+			}
+		},
+		func() { serveTestRequest(t, router, "POST", "/connections/concurrent/renewCertificate", "") },
+		func() { serveTestRequest(t, router, "GET", "/connections", "") },
+		func() { serveTestRequest(t, router, "POST", "/connections/concurrent/eventMode", `{"mode": "legacy"}`) },
+		func() {
+			if _, err := connector.SendEvent("concurrent", "order.created", "v1", json.RawMessage(`{"orderCode": "1"}`)); err != nil {
+				t.Errorf("SendEvent: %s", err)
+			}
+		},
+		func() { connector.GetConnectionStatus("concurrent") },
+	)
+
+	if events := system.events(); len(events) != concurrentRuns {
+		t.Errorf("%d events received, want %d", len(events), concurrentRuns)
+	}
+	if _, err := connector.GetRegistrations("concurrent"); err != nil {
+		t.Errorf("the renewed certificate is not accepted: %s", err)
+	}
+}
+
+//the registrations are tracked with the connection, concurrent changes must not lose any of them
+func TestConcurrentRegistrations(t *testing.T) {
+
+	for _, newSystem := range []func(t *testing.T) testSystem{newKymaSystem, newCompassSystem} {
+		system := newSystem(t)
+		t.Run(system.name, func(t *testing.T) {
+			defer system.close()
+
+			newTestStore()
+			name := system.name + "-concurrent"
+			connectTestSystem(t, name, system)
+
+			var mu sync.Mutex
+			next := 0
+
+			runConcurrently(func() {
+				mu.Lock()
+				specName := fmt.Sprintf("orders-%d", next)
+				next++
+				mu.Unlock()
+
+				spec := connector.SpecDeclaration{Name: specName, Kind: connector.RegistrationKindAPI, File: "api-graphql.yaml"}
+				if _, err := connector.UploadSpec(name, spec, false); err != nil {
+					t.Errorf("UploadSpec %s: %s", specName, err)
+				}
+			})
+
+			info, err := connector.GetConnection(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			//the sample api and events of the connect and the uploaded apis
+			if len(info.Registrations) != 2+concurrentRuns {
+				t.Fatalf("%d registrations are tracked, want %d: %+v", len(info.Registrations), 2+concurrentRuns, info.Registrations)
+			}
+
+			registrations, err := connector.GetRegistrations(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var deleteIDs []string
+			for _, registration := range registrations {
+				if strings.HasPrefix(registration.Name, "orders-") {
+					deleteIDs = append(deleteIDs, registration.ID)
+				}
+			}
+			if len(deleteIDs) != concurrentRuns {
+				t.Fatalf("%d uploaded apis are registered, want %d: %+v", len(deleteIDs), concurrentRuns, registrations)
+			}
+
+			runConcurrently(func() {
+				mu.Lock()
+				id := deleteIDs[0]
+				deleteIDs = deleteIDs[1:]
+				mu.Unlock()
+
+				if err := connector.DeleteRegistration(name, connector.RegistrationKindAPI, id); err != nil {
+					t.Errorf("DeleteRegistration %s: %s", id, err)
+				}
+			})
+
+			if info, _ := connector.GetConnection(name); len(info.Registrations) != 2 {
+				t.Errorf("%d registrations are tracked after the deletes, want the 2 samples: %+v", len(info.Registrations), info.Registrations)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package connector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+)
+
+//ConnectRequest - input of the orchestrated connection process
+type ConnectRequest struct {
+	TokenData       string   `json:"tokenData"`
+	HostURL         string   `json:"hostURL"`
+	KeyAlgorithm    string   `json:"keyAlgorithm,omitempty"`
+	SubjectAltNames []string `json:"subjectAltNames,omitempty"`
+	Force           bool     `json:"force,omitempty"`
+	//the package created for a compass application
+	Package *PackageConfig `json:"package,omitempty"`
+	//legacy, structured or binary, the url replaces the events url of the system
+	EventMode string `json:"eventMode,omitempty"`
+	EventURL  string `json:"eventURL,omitempty"`
+}
+
+//StepReport - result of a single step of the connection process
+type StepReport struct {
+	Step       string `json:"step"`
+	Success    bool   `json:"success"`
+	DurationMs int64  `json:"durationMs"`
+	Response   string `json:"response,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+//ConnectReport - result of the connection process, contains a report for every step that was run
+type ConnectReport struct {
+	Connection       string       `json:"connection"`
+	Success          bool         `json:"success"`
+	ConnectionStatus string       `json:"connectionStatus"`
+	DurationMs       int64        `json:"durationMs"`
+	Steps            []StepReport `json:"steps"`
+}
+
+type connectStep struct {
+	name string
+	run  func() ([]byte, error)
+}
+
+//Connect - runs steps 1 to 5 for the named connection and stops on the first failure
+func Connect(name string, connectReq ConnectRequest) ConnectReport {
+	log.Printf("Connect %s", name)
+
+	config := getOrCreateConnection(name)
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if connectReq.KeyAlgorithm != "" {
+		config.KeyAlgorithm = connectReq.KeyAlgorithm
+	}
+	if len(connectReq.SubjectAltNames) > 0 {
+		config.SubjectAltNames = connectReq.SubjectAltNames
+	}
+	if connectReq.EventMode != "" {
+		config.EventMode = connectReq.EventMode
+	}
+	if connectReq.EventURL != "" {
+		config.EventURL = connectReq.EventURL
+	}
+
+	steps := []connectStep{
+		{"callTokenURL", func() ([]byte, error) { return config.callTokenURL(connectReq.TokenData) }},
+		{"createSecureConnection", config.createSecureConnection},
+		{"getAppInfo", func() ([]byte, error) { return config.getAppInfo(connectReq.Package, []byte(connectReq.HostURL)) }},
+		{"sendAPISpec", func() ([]byte, error) { return config.sendAPISpec([]byte(connectReq.HostURL), connectReq.Force) }},
+		{"sendEventSpec", func() ([]byte, error) { return config.sendEventSpec(connectReq.Force) }},
+	}
+
+	report := ConnectReport{
+		Connection: name,
+		Success:    true,
+		Steps:      []StepReport{},
+	}
+	start := time.Now()
+
+	for _, step := range steps {
+		stepStart := time.Now()
+		resp, err := step.run()
+
+		stepReport := StepReport{
+			Step:       step.name,
+			Success:    err == nil,
+			DurationMs: int64(time.Since(stepStart) / time.Millisecond),
+			Response:   string(resp),
+		}
+		if err != nil {
+			stepReport.Error = err.Error()
+		}
+		report.Steps = append(report.Steps, stepReport)
+
+		if err != nil {
+			log.Printf("Connect %s: step %s failed: %s", name, step.name, err)
+			report.Success = false
+			break
+		}
+	}
+
+	report.DurationMs = int64(time.Since(start) / time.Millisecond)
+	report.ConnectionStatus = config.ConnectionStatus
+	return report
+}
+
+//ConnectAll - runs the whole connection process in one call
+func ConnectAll(w http.ResponseWriter, r *http.Request) {
+	log.Println("ConnectAll")
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.ReturnError("Could not read the body text", w)
+		return
+	}
+
+	var connectReq ConnectRequest
+	if err := json.Unmarshal(body, &connectReq); err != nil {
+		utils.ReturnError("Could not parse the request: "+err.Error(), w)
+		return
+	}
+
+	if connectReq.TokenData == "" {
+		utils.ReturnError("No tokenData provided", w)
+		return
+	}
+
+	if err := CheckEventMode(connectReq.EventMode); err != nil {
+		utils.ReturnError(err.Error(), w)
+		return
+	}
+
+	report := Connect(ConnectionName(r), connectReq)
+
+	if report.Success {
+		utils.ReturnJSON(report, w)
+	} else {
+		utils.ReturnJSONWithStatus(report, http.StatusBadGateway, w)
+	}
+}
@@ -0,0 +1,213 @@
+package connector_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+	"github.com/jcawley/kyma-app-connector/pkg/fake"
+	"github.com/jcawley/kyma-app-connector/pkg/store"
+)
+
+//a system the connection round trip runs against
+type testSystem struct {
+	name           string
+	connectionType string
+	token          func() string
+	events         func() []json.RawMessage
+	close          func()
+}
+
+func newKymaSystem(t *testing.T) testSystem {
+	kyma, err := fake.NewKymaTestServer("test-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSystem{"kyma", "REST", kyma.NewToken, kyma.Events, kyma.Close}
+}
+
+func newCompassSystem(t *testing.T) testSystem {
+	compass, err := fake.NewCompassTestServer("test-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSystem{"compass", "GraphQL", compass.NewToken, compass.Events, compass.Close}
+}
+
+//replaces the credential store so every test starts without connections
+func newTestStore() store.CredentialStore {
+	credentials := store.NewMemoryStore()
+	connector.SetCredentialStore(credentials)
+	return credentials
+}
+
+func connectTestSystem(t *testing.T, name string, system testSystem) {
+	report := connector.Connect(name, connector.ConnectRequest{TokenData: system.token(), HostURL: "http://localhost:8000"})
+	if !report.Success {
+		reportData, _ := json.MarshalIndent(report, "", "  ")
+		t.Fatalf("connecting to %s failed:\n%s", system.name, reportData)
+	}
+}
+
+func TestConnectRenewRevoke(t *testing.T) {
+
+	for _, newSystem := range []func(t *testing.T) testSystem{newKymaSystem, newCompassSystem} {
+		system := newSystem(t)
+		t.Run(system.name, func(t *testing.T) {
+			defer system.close()
+
+			credentials := newTestStore()
+			name := system.name + "-test"
+
+			connectTestSystem(t, name, system)
+
+			info, err := connector.GetConnection(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.ConnectionStatus != "Connected" || info.ConnectionType != system.connectionType {
+				t.Errorf("connection is %s %s, want a connected %s connection", info.ConnectionStatus, info.ConnectionType, system.connectionType)
+			}
+			if info.CertificateExpiresAt == nil {
+				t.Error("the certificate expiry is not known")
+			}
+
+			registrations, err := connector.GetRegistrations(name)
+			if err != nil {
+				t.Fatalf("GetRegistrations: %s", err)
+			}
+			kinds := map[string]int{}
+			for _, registration := range registrations {
+				kinds[registration.Kind]++
+			}
+			if kinds[connector.RegistrationKindAPI] != 1 || kinds[connector.RegistrationKindEvents] != 1 {
+				t.Errorf("registrations = %+v, want the sample api and events", registrations)
+			}
+
+			if _, err := connector.SendEvent(name, "order.created", "v1", json.RawMessage(`{"orderCode": "1"}`)); err != nil {
+				t.Errorf("SendEvent: %s", err)
+			}
+			if events := system.events(); len(events) != 1 {
+				t.Errorf("%d events received, want 1", len(events))
+			}
+
+			certificate, err := credentials.Load(name, "crtChain.crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := connector.RenewConnection(name); err != nil {
+				t.Fatalf("RenewConnection: %s", err)
+			}
+			renewed, err := credentials.Load(name, "crtChain.crt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Equal(certificate, renewed) {
+				t.Error("the certificate was not replaced by the renewal")
+			}
+			if _, err := connector.GetRegistrations(name); err != nil {
+				t.Errorf("the renewed certificate is not accepted: %s", err)
+			}
+
+			//the client of the revoked certificate must be refused afterwards
+			client := connector.GetHTTPTLSClient(name)
+			eventURL := connector.GetEventURL(name)
+
+			if err := connector.DisconnectConnection(name); err != nil {
+				t.Fatalf("DisconnectConnection: %s", err)
+			}
+
+			if _, err := connector.GetConnection(name); err == nil {
+				t.Error("the connection still exists after the disconnect")
+			}
+			if connections, _ := credentials.List("crtChain.crt"); len(connections) != 0 {
+				t.Errorf("credentials of %v are left after the disconnect", connections)
+			}
+
+			resp, err := client.Post(eventURL, "application/json", bytes.NewBufferString(`{}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("the revoked certificate got status %d, want %d", resp.StatusCode, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestRestoreRefusesRevokedCertificates(t *testing.T) {
+
+	system := newKymaSystem(t)
+	defer system.close()
+
+	credentials := newTestStore()
+	connectTestSystem(t, "restored", system)
+
+	connector.SetCredentialStore(credentials)
+	if status := connector.GetConnectionStatus("restored"); !strings.HasPrefix(status, "Connected") {
+		t.Fatalf("restored connection is %q, want connected", status)
+	}
+
+	//revoke with the client of the restored connection, its credentials stay in the store
+	revokeURL, err := url.Parse(connector.GetEventURL("restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	revokeURL.Path = "/v1/applications/certificates/revocations"
+
+	resp, err := connector.GetHTTPTLSClient("restored").Post(revokeURL.String(), "application/json", bytes.NewBufferString(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("revoking the certificate failed with status %d", resp.StatusCode)
+	}
+
+	connector.SetCredentialStore(credentials)
+	if status := connector.GetConnectionStatus("restored"); status != "Not Connected" {
+		t.Errorf("connection with a revoked certificate restored as %q, want Not Connected", status)
+	}
+
+	//the expiry monitor of the revoked certificate is stopped with its status
+	info, err := connector.GetConnection("restored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CertificateExpiresAt != nil || info.CertificateRenewAt != nil {
+		t.Errorf("the revoked certificate is still monitored: %+v", info)
+	}
+}
+
+//the default instance auth of the connect request is passed on, so the mock api can expect it
+func TestConnectReportsRegisteredAuth(t *testing.T) {
+
+	system := newCompassSystem(t)
+	defer system.close()
+	newTestStore()
+
+	var registered []connector.AuthConfig
+	connector.SetAuthRegistered(func(auth connector.AuthConfig) {
+		registered = append(registered, auth)
+	})
+	defer connector.SetAuthRegistered(nil)
+
+	auth := &connector.AuthConfig{Basic: &connector.BasicCredential{Username: "orders", Password: connector.Secret{Value: "orders-password"}}}
+	report := connector.Connect("registered-auth", connector.ConnectRequest{
+		TokenData: system.token(),
+		HostURL:   "http://localhost:8000",
+		Package:   &connector.PackageConfig{Name: "orders-pkg", Auth: auth},
+	})
+	if !report.Success {
+		t.Fatalf("connect failed: %+v", report)
+	}
+
+	if len(registered) != 1 || registered[0].Basic == nil || registered[0].Basic.Username != "orders" {
+		t.Errorf("registered auth = %+v, want the basic auth of the package", registered)
+	}
+}
@@ -0,0 +1,170 @@
+package connector
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+)
+
+//DefaultConnection - name of the connection used by the /api/... routes
+const DefaultConnection string = "default"
+
+//ConnectionNamePattern - allowed connection names, also used as directory names
+const ConnectionNamePattern string = "[a-zA-Z0-9_-]+"
+
+var connectionNameRegexp = regexp.MustCompile("^" + ConnectionNamePattern + "$")
+
+var assetsDir string
+
+var connections = map[string]*apiConfig{}
+var connectionsMu sync.RWMutex
+
+//ConnectionInfo - summary of a connection shown on the index page
+type ConnectionInfo struct {
+	Name                 string         `json:"name"`
+	ConnectionType       string         `json:"connectionType"`
+	ConnectionStatus     string         `json:"connectionStatus"`
+	CertificateStatus    string         `json:"certificateStatus,omitempty"`
+	CertificateExpiresAt *time.Time     `json:"certificateExpiresAt,omitempty"`
+	CertificateRenewAt   *time.Time     `json:"certificateRenewAt,omitempty"`
+	EventMode            string         `json:"eventMode,omitempty"`
+	EventURL             string         `json:"eventURL,omitempty"`
+	Registrations        []Registration `json:"registrations,omitempty"`
+}
+
+//ConnectionName - returns the connection addressed by the request, the default connection if none is given
+func ConnectionName(r *http.Request) string {
+	name := mux.Vars(r)["name"]
+	if name == "" {
+		return DefaultConnection
+	}
+	return name
+}
+
+//GetConnections - lists all connections sorted by name
+func GetConnections() []ConnectionInfo {
+	connectionsMu.RLock()
+	defer connectionsMu.RUnlock()
+
+	infos := []ConnectionInfo{}
+	for _, config := range connections {
+		config.mu.RLock()
+		infos = append(infos, config.info())
+		config.mu.RUnlock()
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+//ListConnections -
+func ListConnections(w http.ResponseWriter, r *http.Request) {
+	log.Println("ListConnections")
+
+	utils.ReturnJSON(GetConnections(), w)
+}
+
+func getConnection(name string) *apiConfig {
+	connectionsMu.RLock()
+	defer connectionsMu.RUnlock()
+
+	return connections[name]
+}
+
+func getOrCreateConnection(name string) *apiConfig {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+
+	if config, ok := connections[name]; ok {
+		return config
+	}
+
+	config := newConnection(name)
+	connections[name] = config
+	return config
+}
+
+func removeConnection(name string) {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+
+	delete(connections, name)
+}
+
+//the summary of the connection including the state of its client certificate
+func (config *apiConfig) info() ConnectionInfo {
+
+	info := ConnectionInfo{
+		Name:             config.Name,
+		ConnectionType:   config.ConnectionType,
+		ConnectionStatus: config.ConnectionStatus,
+		Registrations:    config.registrations(),
+	}
+
+	if config.kc != nil {
+		info.EventMode = config.eventMode()
+		info.EventURL = config.eventURL(info.EventMode)
+	}
+
+	certStatus := config.certificateStatus()
+	if !certStatus.NotAfter.IsZero() {
+		info.CertificateStatus = certStatus.String()
+		info.CertificateExpiresAt = &certStatus.NotAfter
+		if renewal.fraction > 0 {
+			info.CertificateRenewAt = &certStatus.RenewAt
+		}
+	}
+
+	return info
+}
+
+func newConnection(name string) *apiConfig {
+	return &apiConfig{
+		Name:             name,
+		ConnectionStatus: notConnected,
+	}
+}
+
+var restoreOnce sync.Once
+
+//Restore - opens the credential store and restores the connections kept in it, the restored connections are probed
+//and monitored. Call it before the connections are used, later calls do nothing
+func Restore() {
+	restoreOnce.Do(func() {
+		initCredentialStore()
+		restoreConnections()
+	})
+}
+
+//restores every connection that has a snapshot in the credential store
+func restoreConnections() {
+
+	names, err := credentialStore.List(snapshotFile)
+	if err != nil {
+		log.Printf("restoreConnections: %s", err)
+	}
+
+	for _, name := range names {
+		if !connectionNameRegexp.MatchString(name) {
+			continue
+		}
+
+		config := newConnection(name)
+
+		connectionsMu.Lock()
+		connections[name] = config
+		connectionsMu.Unlock()
+
+		config.mu.Lock()
+		if err := config.restoreSnapshot(); err != nil {
+			log.Printf("could not restore the connection %s: %s", name, err)
+		}
+		config.mu.Unlock()
+	}
+}
@@ -0,0 +1,154 @@
+package connector
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jcawley/kyma-app-connector/pkg/store"
+)
+
+//CredentialStoreEnv - where certificates, keys and connection state are kept: file (default), memory or kubernetes
+const CredentialStoreEnv string = "APP_CONN_CREDENTIAL_STORE"
+
+//CredentialsDirEnv - directory of the file store, defaults to assets/kymacerts
+const CredentialsDirEnv string = "APP_CONN_CREDENTIALS_DIR"
+
+//SecretNamespaceEnv - namespace of the secrets of the kubernetes store, defaults to the namespace of the pod
+const SecretNamespaceEnv string = "APP_CONN_SECRET_NAMESPACE"
+
+//SecretPrefixEnv - prefix of the secret names of the kubernetes store, the secrets are named <prefix>-<connection>
+const SecretPrefixEnv string = "APP_CONN_SECRET_PREFIX"
+
+//EncryptionPassphraseEnv - passphrase the credentials are encrypted with before they are stored
+const EncryptionPassphraseEnv string = "APP_CONN_ENCRYPTION_PASSPHRASE"
+
+//EncryptionPassphraseFileEnv - file containing the passphrase, e.g. a mounted secret
+const EncryptionPassphraseFileEnv string = "APP_CONN_ENCRYPTION_PASSPHRASE_FILE"
+
+//EncryptionKeyFileEnv - file containing a 32 byte key, raw, hex or base64 encoded
+const EncryptionKeyFileEnv string = "APP_CONN_ENCRYPTION_KEY_FILE"
+
+//EncryptionMigrateEnv - true accepts the credentials stored before the encryption was enabled and encrypts them,
+//otherwise unencrypted credentials are refused
+const EncryptionMigrateEnv string = "APP_CONN_ENCRYPTION_MIGRATE"
+
+const defaultSecretPrefix string = "kyma-app-conn"
+
+var credentialStore store.CredentialStore
+
+func initCredentialStore() {
+
+	switch storeType := os.Getenv(CredentialStoreEnv); storeType {
+	case "memory":
+		log.Println("credentials are kept in memory, connections are lost when the app stops")
+		credentialStore = store.NewMemoryStore()
+
+	case "kubernetes":
+		prefix := os.Getenv(SecretPrefixEnv)
+		if prefix == "" {
+			prefix = defaultSecretPrefix
+		}
+
+		client, err := store.NewInClusterSecretClient(os.Getenv(SecretNamespaceEnv))
+		if err != nil {
+			//never fall back to a store that loses the credentials on restart
+			log.Fatalf("could not create the kubernetes secret store: %s", err)
+		}
+
+		secretStore, err := store.NewSecretStore(client, prefix)
+		if err != nil {
+			log.Fatalf("could not create the kubernetes secret store: %s", err)
+		}
+
+		log.Printf("credentials are kept in the secrets %s-<connection>", prefix)
+		credentialStore = secretStore
+
+	default:
+		if storeType != "" && storeType != "file" {
+			log.Printf("unknown value %q for %s, credentials are kept in files", storeType, CredentialStoreEnv)
+		}
+
+		dir := os.Getenv(CredentialsDirEnv)
+		if dir == "" {
+			dir = filepath.Join(assetsDir, "kymacerts")
+		}
+		credentialStore = store.NewFileStore(dir, DefaultConnection)
+	}
+
+	encryptedStore, err := newEncryptedStore(credentialStore)
+	if err != nil {
+		//never fall back to unencrypted credentials
+		log.Fatalf("could not unlock the credential store: %s", err)
+	}
+	if encryptedStore != nil {
+		log.Println("credentials are encrypted at rest")
+		if os.Getenv(EncryptionMigrateEnv) == "true" {
+			log.Println("unencrypted credentials are encrypted when they are read")
+			encryptedStore.MigrateUnencrypted()
+		}
+		credentialStore = encryptedStore
+	}
+}
+
+//wraps the store if a passphrase or key is configured, returns nil if the encryption is disabled
+func newEncryptedStore(credentials store.CredentialStore) (*store.EncryptedStore, error) {
+
+	passphrase := []byte(os.Getenv(EncryptionPassphraseEnv))
+	passphraseFile := os.Getenv(EncryptionPassphraseFileEnv)
+	keyFile := os.Getenv(EncryptionKeyFileEnv)
+
+	configured := 0
+	for _, value := range []string{string(passphrase), passphraseFile, keyFile} {
+		if value != "" {
+			configured++
+		}
+	}
+
+	switch {
+	case configured == 0:
+		return nil, nil
+	case configured > 1:
+		return nil, fmt.Errorf("only one of %s, %s and %s can be set", EncryptionPassphraseEnv, EncryptionPassphraseFileEnv, EncryptionKeyFileEnv)
+	case keyFile != "":
+		keyData, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := store.ParseKey(keyData)
+		if err != nil {
+			return nil, err
+		}
+		return store.NewEncryptedStoreWithKey(credentials, key)
+	case passphraseFile != "":
+		passphraseData, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		//mounted files usually end with a new line
+		passphrase = bytes.TrimRight(passphraseData, "\r\n")
+	}
+
+	return store.NewEncryptedStoreWithPassphrase(credentials, passphrase)
+}
+
+//SetCredentialStore - replaces the credential store and restores the connections found in it
+//existing connections are dropped without being disconnected
+func SetCredentialStore(credentials store.CredentialStore) {
+
+	connectionsMu.Lock()
+	for _, config := range connections {
+		config.stopMonitor()
+	}
+	connections = map[string]*apiConfig{}
+	connectionsMu.Unlock()
+
+	//a later Restore must not replace the store
+	restoreOnce.Do(func() {})
+
+	credentialStore = credentials
+	restoreConnections()
+}
@@ -1,12 +1,44 @@
 package connector
 
+import (
+	"encoding/json"
+	"log"
+)
+
+//RegistrationKindAPI - kind of a registered api spec
+const RegistrationKindAPI string = "api"
+
+//RegistrationKindEvents - kind of a registered event spec
+const RegistrationKindEvents string = "events"
+
+//Registration - a service (rest) or definition (graphql) registered for the application
+type Registration struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+//RegistrationDetails - a service (rest) or definition (graphql) as reported by the system
+//a rest service providing an api and events is listed once for each
+type RegistrationDetails struct {
+	ID          string            `json:"id"`
+	Kind        string            `json:"kind"`
+	Name        string            `json:"name"`
+	TargetURL   string            `json:"targetUrl,omitempty"`
+	SpecType    string            `json:"specType,omitempty"`
+	Package     string            `json:"package,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	ContentHash string            `json:"contentHash,omitempty"`
+	Tracked     bool              `json:"tracked"`
+}
+
 //REST API
 
 type restConnector struct {
-	CsrURL      string      `json:"csrUrl"`
-	API         api         `json:"api"`
-	Certificate certificate `json:"certificate"`
-	Urls        urls        `json:"urls"`
+	CsrURL        string         `json:"csrUrl"`
+	API           api            `json:"api"`
+	Certificate   certificate    `json:"certificate"`
+	Urls          urls           `json:"urls"`
+	Registrations []Registration `json:"serviceIds"`
 }
 
 type api struct {
@@ -42,18 +74,38 @@ type csrConnectResponse struct {
 	CaCrt     string `json:"caCrt"`
 }
 
+//ServiceResp contains the id of a service created via the metadata url
+type serviceResp struct {
+	ID string `json:"id"`
+}
+
+//ServiceDetails - a service as returned by the metadata url
+type serviceDetails struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	API    *struct {
+		TargetURL string          `json:"targetUrl"`
+		APIType   string          `json:"apiType"`
+		Spec      json.RawMessage `json:"spec"`
+	} `json:"api"`
+	Events *struct {
+		Spec json.RawMessage `json:"spec"`
+	} `json:"events"`
+}
+
 //GRAPHQL API
 
 //GraphQLConnector -
 type graphQLConnector struct {
 	ConnectorURL          string                    `json:"connectorURL"`
 	Token                 string                    `json:"token"`
-	GraphQLAPIResp        graphQLAPI                `json:"data"`
+	GraphQLAPIResp        graphQLAPI                `json:"configuration"`
 	Certificate           certificate               `json:"certificate"`
-	CsrConnectGraphQLResp csrConnectGraphQLResponse `json:"data"`
-	AppID                 appID                     `json:"data"`
-	EventsURL             eventsURL                 `json:"data"`
-	PackageID             definitionResp            `json:"data"`
+	CsrConnectGraphQLResp csrConnectGraphQLResponse `json:"signedCertificate"`
+	AppID                 appID                     `json:"appID"`
+	EventsURL             eventsURL                 `json:"eventsURL"`
+	PackageID             definitionResp            `json:"packageID"`
+	Registrations         []Registration            `json:"registrations,omitempty"`
 }
 
 //GraphQLAPI -
@@ -85,6 +137,15 @@ type csrConnectGraphQLResponse struct {
 	} `json:"result"`
 }
 
+//the graphql result in the format returned by the rest connector
+func (csrResp csrConnectGraphQLResponse) toCsrConnectResponse() *csrConnectResponse {
+	return &csrConnectResponse{
+		Crt:       csrResp.Result.CertificateChain,
+		ClientCrt: csrResp.Result.ClientCertificate,
+		CaCrt:     csrResp.Result.CaCertificate,
+	}
+}
+
 //AppID -
 type appID struct {
 	Viewer struct {
@@ -107,3 +168,49 @@ type definitionResp struct {
 		ID string `json:"id"`
 	} `json:"result"`
 }
+
+//ApplicationPackages - the packages of the application with their api and event definitions
+type applicationPackages struct {
+	Result struct {
+		Labels   map[string]json.RawMessage `json:"labels"`
+		Packages struct {
+			Data []struct {
+				ID             string `json:"id"`
+				Name           string `json:"name"`
+				APIDefinitions struct {
+					Data []struct {
+						ID          string `json:"id"`
+						Name        string `json:"name"`
+						Description string `json:"description"`
+						TargetURL   string `json:"targetURL"`
+						Spec        *struct {
+							Type string `json:"type"`
+						} `json:"spec"`
+					} `json:"data"`
+				} `json:"apiDefinitions"`
+				EventDefinitions struct {
+					Data []struct {
+						ID          string `json:"id"`
+						Name        string `json:"name"`
+						Description string `json:"description"`
+						Spec        *struct {
+							Type string `json:"type"`
+						} `json:"spec"`
+					} `json:"data"`
+				} `json:"eventDefinitions"`
+			} `json:"data"`
+		} `json:"packages"`
+	} `json:"result"`
+}
+
+//the content hashes recorded by sync by definition id
+func (packages applicationPackages) contentHashes() map[string]string {
+	contentHashes := map[string]string{}
+
+	if label, ok := packages.Result.Labels[contentHashesLabel]; ok {
+		if err := json.Unmarshal(label, &contentHashes); err != nil {
+			log.Printf("label %s is not a map of content hashes: %s", contentHashesLabel, err)
+		}
+	}
+	return contentHashes
+}
@@ -0,0 +1,151 @@
+package connector
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+)
+
+//EventModeRequest - body of SetEventMode, an empty mode falls back to APP_CONN_EVENT_MODE and an empty url to the one of the system
+type EventModeRequest struct {
+	Mode string `json:"mode"`
+	URL  string `json:"url,omitempty"`
+}
+
+//SendEvent - publishes an event to the events url of the named connection in the mode of the connection
+func SendEvent(name string, eventType string, eventTypeVersion string, data json.RawMessage) ([]byte, error) {
+	return PublishEvent(name, "", eventType, eventTypeVersion, data)
+}
+
+//PublishEvent - publishes an event in the given mode, an empty mode is the mode of the connection
+func PublishEvent(name string, mode string, eventType string, eventTypeVersion string, data json.RawMessage) ([]byte, error) {
+
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.RLock()
+	defer config.mu.RUnlock()
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	if mode == "" {
+		mode = config.eventMode()
+	}
+	log.Printf("SendEvent %s %s as %s", eventType, eventTypeVersion, mode)
+
+	publisher, err := newPublisher(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	eventURL := config.eventURL(mode)
+	if eventURL == "" {
+		return nil, errors.New("no EventsURL exists")
+	}
+
+	source := applicationName(config.kc.getEventURL())
+	if source == "" {
+		source = config.Name
+	}
+
+	req, err := publisher.request(eventURL, event{
+		ID:      newEventID(),
+		Time:    time.Now(),
+		Type:    eventType,
+		Version: eventTypeVersion,
+		Source:  source,
+		Data:    data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := config.HTTPTLSClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("sending the event failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	//binary and structured publishing may answer without a body
+	if len(respBody) == 0 {
+		respBody = []byte(fmt.Sprintf("event published with status %d", resp.StatusCode))
+	}
+	return respBody, nil
+}
+
+//SetEventMode - sets the event mode and url of the connection
+func SetEventMode(w http.ResponseWriter, r *http.Request) {
+	log.Println("SetEventMode")
+
+	var modeReq EventModeRequest
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&modeReq); err != nil {
+		utils.ReturnError("Could not parse the event mode: "+err.Error(), w)
+		return
+	}
+
+	info, err := SetConnectionEventMode(ConnectionName(r), modeReq)
+	if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnJSON(info, w)
+	}
+}
+
+//SetConnectionEventMode - sets the event mode and url of the named connection and stores them with it
+func SetConnectionEventMode(name string, modeReq EventModeRequest) (*ConnectionInfo, error) {
+
+	if err := CheckEventMode(modeReq.Mode); err != nil {
+		return nil, err
+	}
+
+	config := getConnection(name)
+	if config == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.mu.Lock()
+	defer config.mu.Unlock()
+
+	if config.kc == nil {
+		return nil, errNoTLSConnection
+	}
+
+	config.EventMode = modeReq.Mode
+	config.EventURL = modeReq.URL
+	config.persist()
+
+	info := config.info()
+	return &info, nil
+}
+
+//generates a random (version 4) uuid
+func newEventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 
@@ -26,9 +27,11 @@ func (KymaConn *graphQLConnector) callTokenURL(tokenData string) ([]byte, error)
 		return nil, err
 	}
 
-	json.Unmarshal(tokenDataDecoded, &KymaConn)
+	if err := json.Unmarshal(tokenDataDecoded, &KymaConn); err != nil {
+		return nil, fmt.Errorf("invalid token data: %s", err)
+	}
 
-	client := graphql.NewClient(KymaConn.ConnectorURL)
+	client := graphql.NewClient(KymaConn.ConnectorURL, graphql.WithHTTPClient(statusCheckingClient(publicClient)))
 
 	req := graphql.NewRequest(`
 		query {
@@ -62,11 +65,45 @@ func (KymaConn *graphQLConnector) callTokenURL(tokenData string) ([]byte, error)
 	return []byte(fmt.Sprintf("%+v\n", KymaConn.GraphQLAPIResp)), nil
 }
 
+//statusTransport - returns error statuses as errors with the body
+//the graphql client only reports a body that is no json, or nothing if it is
+type statusTransport struct {
+	transport http.RoundTripper
+}
+
+func (st statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	resp, err := st.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return resp, nil
+}
+
+//the client with a transport refusing error statuses
+func statusCheckingClient(client *http.Client) *http.Client {
+
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	checking := *client
+	checking.Transport = statusTransport{transport}
+	return &checking
+}
+
 //SendCSRToKyma - STEP 2
-func (KymaConn *graphQLConnector) sendCSRToKyma(csr []byte) ([]byte, error) {
+func (KymaConn *graphQLConnector) sendCSRToKyma(csr []byte) (*csrConnectResponse, error) {
 	log.Println("SendCSRToKyma via via graphql...")
 
-	client := graphql.NewClient(KymaConn.ConnectorURL)
+	client := graphql.NewClient(KymaConn.ConnectorURL, graphql.WithHTTPClient(statusCheckingClient(publicClient)))
 
 	req := graphql.NewRequest(`
 	mutation ($csrBase64: String!) {
@@ -91,15 +128,14 @@ func (KymaConn *graphQLConnector) sendCSRToKyma(csr []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	// log.Println("GraphQLAPI Result..............")
-	fmt.Printf("%+v\n", KymaConn)
+	log.Println("SendCSRToKyma: certificate chain received")
 
-	return []byte(KymaConn.CsrConnectGraphQLResp.Result.CertificateChain), nil
+	return KymaConn.CsrConnectGraphQLResp.toCsrConnectResponse(), nil
 }
 
 //GetAppInfo -  STEP 3
 //Gets appid, eventsurl
-func (KymaConn *graphQLConnector) getAppInfo(TLSClient *http.Client) ([]byte, error) {
+func (KymaConn *graphQLConnector) getAppInfo(TLSClient *http.Client, pkg PackageConfig) ([]byte, error) {
 
 	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
 
@@ -115,7 +151,7 @@ func (KymaConn *graphQLConnector) getAppInfo(TLSClient *http.Client) ([]byte, er
 		return nil, err
 	}
 
-	err = KymaConn.createPackage(client)
+	err = KymaConn.createPackage(client, pkg)
 	if err != nil {
 		return nil, err
 	}
@@ -141,7 +177,7 @@ func (KymaConn *graphQLConnector) getAppID(client *graphql.Client) error {
 	if err := client.Run(ctx, req, &KymaConn.AppID); err != nil {
 		return err
 	}
-	fmt.Printf("%+v\n", KymaConn)
+	log.Printf("getAppID: %s", KymaConn.AppID.Viewer.ID)
 
 	return nil
 
@@ -166,16 +202,26 @@ func (KymaConn *graphQLConnector) getEventsURL(client *graphql.Client) error {
 	if err := client.Run(ctx, req, &KymaConn.EventsURL); err != nil {
 		return err
 	}
-	fmt.Printf("%+v\n", KymaConn)
+	log.Printf("getEventsURL: eventing configuration of %s received", KymaConn.AppID.Viewer.ID)
 
 	return nil
 }
 
 //createPackage -  STEP 3c
-func (KymaConn *graphQLConnector) createPackage(client *graphql.Client) error {
+//no package is created without a name, e.g. when a restored connection is verified
+func (KymaConn *graphQLConnector) createPackage(client *graphql.Client, pkg PackageConfig) error {
 	log.Println("createPackage via graphql...")
 	log.Println(KymaConn.AppID.Viewer.ID)
 
+	if KymaConn.PackageID.Result.ID != "" {
+		log.Printf("package %s already exists", KymaConn.PackageID.Result.ID)
+		return nil
+	}
+
+	if pkg.Name == "" {
+		return nil
+	}
+
 	req := graphql.NewRequest(`
 	mutation ($appID: ID!, $payload: PackageCreateInput!){
 		result: addPackage(
@@ -187,94 +233,377 @@ func (KymaConn *graphQLConnector) createPackage(client *graphql.Client) error {
 		}
 	`)
 
-	PackageInputJSON := `{
-			"name":"comm-demo-pkg", 
-			"description": "Kyma Comm Demo App Package", 
-			"defaultInstanceAuth" : {
-				"credential": {
-					"basic": {
-						"username": "user",
-						"password": "password"
-					}
-				},
-				"additionalHeaders": {
-					"header1": ["header1value"]
-				},
-				"additionalQueryParams": {
-					"query1": ["query1value"]
-				}
-			}
-	}`
-	PackageInput := make(map[string]interface{})
-
-	if err := json.Unmarshal([]byte(PackageInputJSON), &PackageInput); err != nil {
-		fmt.Printf(err.Error())
+	PackageInput, err := pkg.input()
+	if err != nil {
+		log.Println(err.Error())
 		return err
 	}
 
 	req.Var("appID", KymaConn.AppID.Viewer.ID)
-	req.Var("payload", &PackageInput)
+	req.Var("payload", PackageInput)
 
 	ctx := context.Background()
 
 	if err := client.Run(ctx, req, &KymaConn.PackageID); err != nil {
-		fmt.Printf(err.Error())
+		log.Println(err.Error())
 		return err
 	}
-	fmt.Printf("%+v\n", KymaConn)
+	log.Printf("created package %s (%s)", pkg.Name, KymaConn.PackageID.Result.ID)
+
+	if pkg.Auth != nil && authRegistered != nil {
+		authRegistered(*pkg.Auth)
+	}
 
 	return nil
 
 }
 
+//names of the sample definitions
+const sampleAPIName string = "Sample Order API - MP"
+const sampleEventName string = "Sample Order Event - MP"
+
 //SendEventSpec -
 func (KymaConn *graphQLConnector) sendEventSpec(TLSClient *http.Client, eventSpec []byte) ([]byte, error) {
 	log.Println("SendEventMetadata via graphql...")
 
+	id, err := KymaConn.saveEventDefinition(TLSClient, "", sampleEventName, "", eventSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("{ID: %s}", id)), nil
+}
+
+//SendAPISpec -
+func (KymaConn *graphQLConnector) sendAPISpec(TLSClient *http.Client, apiSpec []byte, hostURL []byte) ([]byte, error) {
+
+	log.Println("SendAPIMetadata via graphql...")
+
+	id, err := KymaConn.saveAPIDefinition(TLSClient, "", sampleAPIName, "", apiSpec, hostURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("{ID: %s}", id)), nil
+}
+
+//UpdateAPISpec - replaces the api definition with the id
+func (KymaConn *graphQLConnector) updateAPISpec(TLSClient *http.Client, id string, apiSpec []byte, hostURL []byte) ([]byte, error) {
+	log.Printf("UpdateAPIDefinition %s via graphql...", id)
+
+	//only the spec is replaced, the definition keeps its name and description
+	name, description, err := KymaConn.getDefinition(TLSClient, id, RegistrationKindAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err = KymaConn.saveAPIDefinition(TLSClient, id, name, description, apiSpec, hostURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("{ID: %s}", id)), nil
+}
+
+//UpdateEventSpec - replaces the event definition with the id
+func (KymaConn *graphQLConnector) updateEventSpec(TLSClient *http.Client, id string, eventSpec []byte) ([]byte, error) {
+	log.Printf("UpdateEventDefinition %s via graphql...", id)
+
+	//only the spec is replaced, the definition keeps its name and description
+	name, description, err := KymaConn.getDefinition(TLSClient, id, RegistrationKindEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err = KymaConn.saveEventDefinition(TLSClient, id, name, description, eventSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("{ID: %s}", id)), nil
+}
+
+//the name and description of the api or event definition with the id
+func (KymaConn *graphQLConnector) getDefinition(TLSClient *http.Client, id string, kind string) (string, string, error) {
+
 	if KymaConn.AppID.Viewer.ID == "" {
-		return nil, errors.New("no AppId exists")
+		return "", "", errors.New("no AppId exists")
 	}
 
 	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
 
 	req := graphql.NewRequest(`
-	mutation ($packageID: ID!, $eventSpec: CLOB!){
-		result: addEventDefinitionToPackage(
-			packageID: $packageID
-		  	in: {
-				name: "Sample Order Event - MP"
-				spec: {
-			  		type: ASYNC_API
-			  		format: YAML
-			  		data: $eventSpec
-			  	}
-		  	} 	
-		){
-			id
-		  }
+	query ($appId: ID!){
+		result: application(id: $appId){
+			packages {
+				data {
+					apiDefinitions {
+						data {
+							id
+							name
+							description
+						}
+					}
+					eventDefinitions {
+						data {
+							id
+							name
+							description
+						}
+					}
+				}
+			}
 		}
+	}
 	`)
 
-	req.Var("packageID", KymaConn.PackageID.Result.ID)
-	req.Var("eventSpec", string(eventSpec))
+	req.Var("appId", KymaConn.AppID.Viewer.ID)
+
+	ctx := context.Background()
+
+	var packagesResp applicationPackages
+	if err := client.Run(ctx, req, &packagesResp); err != nil {
+		return "", "", err
+	}
+
+	for _, pkg := range packagesResp.Result.Packages.Data {
+		if kind == RegistrationKindEvents {
+			for _, eventDef := range pkg.EventDefinitions.Data {
+				if eventDef.ID == id {
+					return eventDef.Name, eventDef.Description, nil
+				}
+			}
+			continue
+		}
+		for _, apiDef := range pkg.APIDefinitions.Data {
+			if apiDef.ID == id {
+				return apiDef.Name, apiDef.Description, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no definition with the id %s exists", id)
+}
+
+//SaveSpec - creates or replaces the definition and records its content hash in the application labels
+func (KymaConn *graphQLConnector) saveSpec(TLSClient *http.Client, id string, spec SpecDeclaration, contentHash string) (string, error) {
+	log.Printf("SaveSpec %s via graphql...", spec.Name)
+
+	//definitions have no labels
+	if len(spec.Labels) > 0 {
+		log.Printf("The labels of %s are not registered, compass definitions have no labels", spec.Name)
+	}
+
+	var err error
+	if spec.Kind == RegistrationKindEvents {
+		id, err = KymaConn.saveEventDefinition(TLSClient, id, spec.Name, spec.Description, spec.document())
+	} else {
+		id, err = KymaConn.saveAPIDefinition(TLSClient, id, spec.Name, spec.Description, spec.document(), []byte(spec.TargetURL))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return id, KymaConn.setContentHash(TLSClient, id, contentHash)
+}
+
+//adds the api definition to the package, or replaces the one with the id
+func (KymaConn *graphQLConnector) saveAPIDefinition(TLSClient *http.Client, id string, name string, description string, apiSpec []byte, hostURL []byte) (string, error) {
+
+	if KymaConn.AppID.Viewer.ID == "" {
+		return "", errors.New("no AppId exists")
+	}
+
+	var req *graphql.Request
+	if id == "" {
+		req = graphql.NewRequest(`
+		mutation ($packageID: ID!, $in: APIDefinitionInput!){
+			result: addAPIDefinitionToPackage(packageID: $packageID, in: $in){
+				id
+			}
+		}
+		`)
+		req.Var("packageID", KymaConn.PackageID.Result.ID)
+	} else {
+		req = graphql.NewRequest(`
+		mutation ($id: ID!, $in: APIDefinitionInput!){
+			result: updateAPIDefinition(id: $id, in: $in){
+				id
+			}
+		}
+		`)
+		req.Var("id", id)
+	}
+
+	req.Var("in", map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"targetURL":   string(hostURL),
+		"spec": map[string]interface{}{
+			"type":   "OPEN_API",
+			"format": specFormat(apiSpec),
+			"data":   string(apiSpec),
+		},
+	})
+
+	return KymaConn.saveDefinition(TLSClient, req, RegistrationKindAPI)
+}
+
+//adds the event definition to the package, or replaces the one with the id
+func (KymaConn *graphQLConnector) saveEventDefinition(TLSClient *http.Client, id string, name string, description string, eventSpec []byte) (string, error) {
+
+	if KymaConn.AppID.Viewer.ID == "" {
+		return "", errors.New("no AppId exists")
+	}
+
+	var req *graphql.Request
+	if id == "" {
+		req = graphql.NewRequest(`
+		mutation ($packageID: ID!, $in: EventDefinitionInput!){
+			result: addEventDefinitionToPackage(packageID: $packageID, in: $in){
+				id
+			}
+		}
+		`)
+		req.Var("packageID", KymaConn.PackageID.Result.ID)
+	} else {
+		req = graphql.NewRequest(`
+		mutation ($id: ID!, $in: EventDefinitionInput!){
+			result: updateEventDefinition(id: $id, in: $in){
+				id
+			}
+		}
+		`)
+		req.Var("id", id)
+	}
+
+	req.Var("in", map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"spec": map[string]interface{}{
+			"type":   "ASYNC_API",
+			"format": specFormat(eventSpec),
+			"data":   string(eventSpec),
+		},
+	})
+
+	return KymaConn.saveDefinition(TLSClient, req, RegistrationKindEvents)
+}
+
+//runs the add or update mutation and tracks the definition
+func (KymaConn *graphQLConnector) saveDefinition(TLSClient *http.Client, req *graphql.Request, kind string) (string, error) {
+
+	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
 
 	var specDefResp definitionResp
 
 	ctx := context.Background()
 
 	if err := client.Run(ctx, req, &specDefResp); err != nil {
-		fmt.Printf(err.Error())
-		return nil, err
+		log.Println(err.Error())
+		return "", err
 	}
-	fmt.Printf("%+v\n", KymaConn)
+	log.Printf("saveDefinition: %s definition %s saved", kind, specDefResp.Result.ID)
+
+	KymaConn.Registrations = trackRegistration(KymaConn.Registrations, specDefResp.Result.ID, kind)
 
-	return []byte(fmt.Sprintf("{ID: %s}", specDefResp.Result.ID)), nil
+	return specDefResp.Result.ID, nil
 }
 
-//SendAPISpec -
-func (KymaConn *graphQLConnector) sendAPISpec(TLSClient *http.Client, apiSpec []byte, hostURL []byte) ([]byte, error) {
+//the specs are sent as text, json documents are declared as such
+func specFormat(spec []byte) string {
+	if json.Valid(spec) {
+		return "JSON"
+	}
+	return "YAML"
+}
 
-	log.Println("SendAPIMetadata via graphql...")
+//compass definitions have no labels, so the hashes of all definitions are kept in one application label
+func (KymaConn *graphQLConnector) setContentHash(TLSClient *http.Client, id string, contentHash string) error {
+
+	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
+
+	labelsReq := graphql.NewRequest(`
+	query ($appId: ID!){
+		result: application(id: $appId){
+			labels
+		}
+	}
+	`)
+	labelsReq.Var("appId", KymaConn.AppID.Viewer.ID)
+
+	ctx := context.Background()
+
+	var labelsResp applicationPackages
+	if err := client.Run(ctx, labelsReq, &labelsResp); err != nil {
+		return err
+	}
+
+	contentHashes := labelsResp.contentHashes()
+	contentHashes[id] = contentHash
+
+	req := graphql.NewRequest(`
+	mutation ($appId: ID!, $key: String!, $value: Any!){
+		result: setApplicationLabel(applicationID: $appId, key: $key, value: $value){
+			key
+		}
+	}
+	`)
+	req.Var("appId", KymaConn.AppID.Viewer.ID)
+	req.Var("key", contentHashesLabel)
+	req.Var("value", contentHashes)
+
+	var labelResp struct {
+		Result struct {
+			Key string `json:"key"`
+		} `json:"result"`
+	}
+	return client.Run(ctx, req, &labelResp)
+}
+
+//DeleteAPISpec - deletes the api definition with the id
+func (KymaConn *graphQLConnector) deleteAPISpec(TLSClient *http.Client, id string) error {
+	log.Println("DeleteAPIDefinition via graphql...")
+
+	return KymaConn.deleteDefinition(TLSClient, "deleteAPIDefinition", id)
+}
+
+//DeleteEventSpec - deletes the event definition with the id
+func (KymaConn *graphQLConnector) deleteEventSpec(TLSClient *http.Client, id string) error {
+	log.Println("DeleteEventDefinition via graphql...")
+
+	return KymaConn.deleteDefinition(TLSClient, "deleteEventDefinition", id)
+}
+
+//runs the delete mutation, it has the same shape for api and event definitions
+func (KymaConn *graphQLConnector) deleteDefinition(TLSClient *http.Client, mutation string, id string) error {
+
+	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
+
+	req := graphql.NewRequest(fmt.Sprintf(`
+	mutation ($id: ID!){
+		result: %s(id: $id){
+			id
+		}
+	}
+	`, mutation))
+
+	req.Var("id", id)
+
+	ctx := context.Background()
+
+	var deleteResp definitionResp
+	if err := client.Run(ctx, req, &deleteResp); err != nil {
+		return err
+	}
+
+	KymaConn.Registrations = untrackRegistration(KymaConn.Registrations, id)
+	return nil
+}
+
+//ListRegistrations - lists the api and event definitions of all packages of the application
+func (KymaConn *graphQLConnector) listRegistrations(TLSClient *http.Client) ([]RegistrationDetails, error) {
+	log.Println("ListRegistrations via graphql...")
 
 	if KymaConn.AppID.Viewer.ID == "" {
 		return nil, errors.New("no AppId exists")
@@ -283,38 +612,185 @@ func (KymaConn *graphQLConnector) sendAPISpec(TLSClient *http.Client, apiSpec []
 	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
 
 	req := graphql.NewRequest(`
-	mutation ($packageID: ID!, $apiSpec: CLOB!, $hostURL: String!){
-		result: addAPIDefinitionToPackage(
-			packageID: $packageID
-		  	in: {
-				name: "Sample Order API - MP"
-				targetURL: $hostURL
-				spec: {
-			  		type: OPEN_API
-			  		format: YAML
-			  		data: $apiSpec
+	query ($appId: ID!){
+		result: application(id: $appId){
+			labels
+			packages {
+				data {
+					id
+					name
+					apiDefinitions {
+						data {
+							id
+							name
+							targetURL
+							spec {
+								type
+							}
+						}
+					}
+					eventDefinitions {
+						data {
+							id
+							name
+							spec {
+								type
+							}
+						}
+					}
 				}
 			}
-		){
-			id
-		  }
 		}
+	}
 	`)
 
-	req.Var("packageID", KymaConn.PackageID.Result.ID)
-	req.Var("apiSpec", string(apiSpec))
-	req.Var("hostURL", string(hostURL))
+	req.Var("appId", KymaConn.AppID.Viewer.ID)
 
-	var specDefResp definitionResp
+	ctx := context.Background()
+
+	var packagesResp applicationPackages
+	if err := client.Run(ctx, req, &packagesResp); err != nil {
+		return nil, err
+	}
+
+	registrations := []RegistrationDetails{}
+	contentHashes := packagesResp.contentHashes()
+
+	for _, pkg := range packagesResp.Result.Packages.Data {
+		for _, apiDef := range pkg.APIDefinitions.Data {
+			registration := RegistrationDetails{
+				ID:          apiDef.ID,
+				Kind:        RegistrationKindAPI,
+				Name:        apiDef.Name,
+				TargetURL:   apiDef.TargetURL,
+				Package:     pkg.Name,
+				ContentHash: contentHashes[apiDef.ID],
+			}
+			if apiDef.Spec != nil {
+				registration.SpecType = apiDef.Spec.Type
+			}
+			registrations = append(registrations, registration)
+		}
+
+		for _, eventDef := range pkg.EventDefinitions.Data {
+			registration := RegistrationDetails{
+				ID:          eventDef.ID,
+				Kind:        RegistrationKindEvents,
+				Name:        eventDef.Name,
+				Package:     pkg.Name,
+				ContentHash: contentHashes[eventDef.ID],
+			}
+			if eventDef.Spec != nil {
+				registration.SpecType = eventDef.Spec.Type
+			}
+			registrations = append(registrations, registration)
+		}
+	}
+
+	return registrations, nil
+}
+
+//RenewCertificate - signs a new csr via the certificate secured connector using the current client certificate
+func (KymaConn *graphQLConnector) renewCertificate(TLSClient *http.Client, csr []byte) (*csrConnectResponse, error) {
+	log.Println("RenewCertificate via graphql...")
+
+	securedConnectorURL := KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.CertificateSecuredConnectorURL
+
+	if securedConnectorURL == "" {
+		return nil, errors.New("no CertificateSecuredConnectorURL exists")
+	}
+
+	client := graphql.NewClient(securedConnectorURL, graphql.WithHTTPClient(TLSClient))
+
+	req := graphql.NewRequest(`
+	mutation ($csrBase64: String!) {
+		result: signCertificateSigningRequest(csr: $csrBase64) {
+			certificateChain
+			caCertificate
+			clientCertificate
+		}
+	}
+	`)
+
+	req.Var("csrBase64", base64.StdEncoding.EncodeToString(csr))
 
 	ctx := context.Background()
 
-	if err := client.Run(ctx, req, &specDefResp); err != nil {
+	var csrRespData csrConnectGraphQLResponse
+	if err := client.Run(ctx, req, &csrRespData); err != nil {
 		return nil, err
 	}
-	fmt.Printf("%+v\n", KymaConn)
+	KymaConn.CsrConnectGraphQLResp = csrRespData
 
-	return []byte(fmt.Sprintf("{ID: %s}", specDefResp.Result.ID)), nil
+	return csrRespData.toCsrConnectResponse(), nil
+}
+
+//RevokeCertificate - revokes the current client certificate via the certificate secured connector
+func (KymaConn *graphQLConnector) revokeCertificate(TLSClient *http.Client) error {
+	log.Println("RevokeCertificate via graphql...")
+
+	securedConnectorURL := KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.CertificateSecuredConnectorURL
+
+	if securedConnectorURL == "" {
+		return errors.New("no CertificateSecuredConnectorURL exists")
+	}
+
+	client := graphql.NewClient(securedConnectorURL, graphql.WithHTTPClient(TLSClient))
+
+	req := graphql.NewRequest(`
+	mutation {
+		result: revokeCertificate
+	}
+	`)
+
+	ctx := context.Background()
+
+	var revokeResp struct {
+		Result bool `json:"result"`
+	}
+	if err := client.Run(ctx, req, &revokeResp); err != nil {
+		return err
+	}
+
+	if !revokeResp.Result {
+		return errors.New("certificate was not revoked")
+	}
+
+	return nil
+}
+
+//UnregisterServices - deletes the package created in getAppInfo including its api and event definitions
+func (KymaConn *graphQLConnector) unregisterServices(TLSClient *http.Client) error {
+	log.Println("UnregisterServices via graphql...")
+
+	if KymaConn.PackageID.Result.ID == "" {
+		return nil
+	}
+
+	client := graphql.NewClient(KymaConn.GraphQLAPIResp.Result.ManagementPlaneInfo.DirectorURL, graphql.WithHTTPClient(TLSClient))
+
+	req := graphql.NewRequest(`
+	mutation ($packageID: ID!){
+		result: deletePackage(id: $packageID){
+			id
+		}
+	}
+	`)
+
+	req.Var("packageID", KymaConn.PackageID.Result.ID)
+
+	ctx := context.Background()
+
+	var deleteResp definitionResp
+	if err := client.Run(ctx, req, &deleteResp); err != nil {
+		return err
+	}
+
+	KymaConn.PackageID = definitionResp{}
+	//the definitions were removed with the package
+	KymaConn.Registrations = nil
+
+	return nil
 }
 
 func (KymaConn *graphQLConnector) getCertificateSubject() string {
@@ -323,6 +799,19 @@ func (KymaConn *graphQLConnector) getCertificateSubject() string {
 	return KymaConn.GraphQLAPIResp.Result.CertificateSigningRequestInfo.Subject
 }
 
+func (KymaConn *graphQLConnector) getKeyAlgorithm() string {
+	return KymaConn.GraphQLAPIResp.Result.CertificateSigningRequestInfo.KeyAlgorithm
+}
+
+func (KymaConn *graphQLConnector) getCertificateExtensions() string {
+	//the compass connector does not advertise extensions
+	return ""
+}
+
+func (KymaConn *graphQLConnector) getRegistrations() []Registration {
+	return KymaConn.Registrations
+}
+
 func (KymaConn *graphQLConnector) getEventURL() string {
 	log.Println("getEventURL via graphql")
 
@@ -0,0 +1,232 @@
+package connector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+//RenewFractionEnv - fraction of the certificate lifetime after which it is renewed automatically, 0 disables the renewal
+const RenewFractionEnv string = "APP_CONN_RENEW_FRACTION"
+
+//RenewRetryEnv - delay before a failed renewal is retried, doubled for every further failure
+const RenewRetryEnv string = "APP_CONN_RENEW_RETRY"
+
+//RenewMaxRetryEnv - upper bound of the retry delay
+const RenewMaxRetryEnv string = "APP_CONN_RENEW_MAX_RETRY"
+
+//renewalSettings - when the expiry monitor renews the client certificates
+type renewalSettings struct {
+	fraction      float64
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+}
+
+var renewal = renewalSettings{
+	fraction:      0.7,
+	retryDelay:    30 * time.Second,
+	maxRetryDelay: 30 * time.Minute,
+}
+
+//certificateStatus - validity of the current client certificate and the state of its automatic renewal
+type certificateStatus struct {
+	NotBefore    time.Time
+	NotAfter     time.Time
+	RenewAt      time.Time
+	RenewalError string
+	NextRetry    time.Time
+}
+
+func initRenewal() {
+
+	if value := os.Getenv(RenewFractionEnv); value != "" {
+		fraction, err := strconv.ParseFloat(value, 64)
+		if err != nil || fraction < 0 || fraction >= 1 {
+			log.Printf("invalid value %q for %s, expected a number between 0 and 1", value, RenewFractionEnv)
+		} else {
+			renewal.fraction = fraction
+		}
+	}
+
+	for env, delay := range map[string]*time.Duration{RenewRetryEnv: &renewal.retryDelay, RenewMaxRetryEnv: &renewal.maxRetryDelay} {
+		if value := os.Getenv(env); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				log.Printf("invalid value %q for %s, expected a duration like 30s", value, env)
+				continue
+			}
+			*delay = parsed
+		}
+	}
+
+	if renewal.fraction == 0 {
+		log.Println("automatic certificate renewal is disabled")
+	}
+}
+
+//records the validity of the certificate now in use, a renewal failure no longer applies to it
+//obtained - when the certificate was issued to the connection, CAs usually backdate NotBefore
+func (config *apiConfig) recordCertificate(keyPair *tls.Certificate, obtained time.Time) {
+
+	if len(keyPair.Certificate) == 0 {
+		return
+	}
+
+	leaf, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		log.Printf("recordCertificate: %s", err)
+		return
+	}
+
+	start := leaf.NotBefore
+	if obtained.After(start) {
+		start = obtained
+	}
+	lifetime := leaf.NotAfter.Sub(start)
+
+	config.certMu.Lock()
+	config.certStatus = certificateStatus{
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+		RenewAt:   start.Add(time.Duration(float64(lifetime) * renewal.fraction)),
+	}
+	config.certMu.Unlock()
+
+	log.Printf("certificate of %s expires at %s", config.Name, leaf.NotAfter.Format(time.RFC3339))
+}
+
+func (config *apiConfig) recordRenewalFailure(err error, nextRetry time.Time) {
+	config.certMu.Lock()
+	config.certStatus.RenewalError = err.Error()
+	config.certStatus.NextRetry = nextRetry
+	config.certMu.Unlock()
+}
+
+func (config *apiConfig) certificateStatus() certificateStatus {
+	config.certMu.Lock()
+	defer config.certMu.Unlock()
+	return config.certStatus
+}
+
+//starts the expiry monitor of the connection, a running monitor picks up the new certificate
+func (config *apiConfig) startMonitor() {
+
+	if renewal.fraction == 0 {
+		return
+	}
+
+	config.certMu.Lock()
+	defer config.certMu.Unlock()
+
+	if config.monitorStop != nil {
+		select {
+		case config.monitorWake <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	config.monitorStop = make(chan struct{})
+	config.monitorWake = make(chan struct{}, 1)
+	go config.monitor(config.monitorStop, config.monitorWake)
+}
+
+func (config *apiConfig) stopMonitor() {
+
+	config.certMu.Lock()
+	defer config.certMu.Unlock()
+
+	if config.monitorStop != nil {
+		close(config.monitorStop)
+		config.monitorStop = nil
+		config.monitorWake = nil
+	}
+	config.certStatus = certificateStatus{}
+}
+
+//renews the certificate once the configured fraction of its lifetime has passed, failures are retried with backoff
+func (config *apiConfig) monitor(stop <-chan struct{}, wake <-chan struct{}) {
+
+	var retryDelay time.Duration
+
+	for {
+		wait := time.Until(config.certificateStatus().RenewAt)
+		if retryDelay > 0 {
+			wait = retryDelay
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+			retryDelay = 0
+			continue
+		case <-timer.C:
+		}
+
+		log.Printf("monitor: renewing the certificate of %s", config.Name)
+
+		config.mu.Lock()
+		err := config.renewCertificate()
+		if err == nil {
+			config.persist()
+		}
+		config.mu.Unlock()
+
+		//the connection was disconnected while the renewal waited for it
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if err == nil {
+			retryDelay = 0
+			continue
+		}
+
+		if retryDelay == 0 {
+			retryDelay = renewal.retryDelay
+		} else if retryDelay *= 2; retryDelay > renewal.maxRetryDelay {
+			retryDelay = renewal.maxRetryDelay
+		}
+
+		log.Printf("monitor: renewal of %s failed, retrying in %s: %s", config.Name, retryDelay, err)
+		config.recordRenewalFailure(err, time.Now().Add(retryDelay))
+	}
+}
+
+//e.g. "expires in 16h48m0s" or "renewal failed (retry in 1m0s): ..., expires in 5m0s"
+func (status certificateStatus) String() string {
+
+	if status.NotAfter.IsZero() {
+		return ""
+	}
+
+	expiry := "expired"
+	if remaining := time.Until(status.NotAfter); remaining > 0 {
+		expiry = "expires in " + roundDuration(remaining).String()
+	}
+
+	if status.RenewalError != "" {
+		return fmt.Sprintf("renewal failed (retry in %s): %s, %s", roundDuration(time.Until(status.NextRetry)), status.RenewalError, expiry)
+	}
+	return expiry
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	if d > time.Hour {
+		return d.Round(time.Minute)
+	}
+	return d.Round(time.Second)
+}
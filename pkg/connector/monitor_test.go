@@ -0,0 +1,107 @@
+package connector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newTestCertificate(t *testing.T, notBefore time.Time, notAfter time.Time) *tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-app"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{certificate}, PrivateKey: key}
+}
+
+func TestRecordCertificate(t *testing.T) {
+
+	//APP_CONN_RENEW_FRACTION of the environment does not apply
+	defer func(settings renewalSettings) { renewal = settings }(renewal)
+	renewal.fraction = 0.5
+
+	now := time.Now().Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		obtained  time.Time
+		renewAt   time.Time
+	}{
+		{"issued when obtained", now, now, now.Add(50 * time.Hour)},
+		//the lifetime counts from when the certificate was obtained, not from the backdated NotBefore
+		{"backdated", now.Add(-24 * time.Hour), now, now.Add(50 * time.Hour)},
+		{"restored", now.Add(-50 * time.Hour), time.Time{}, now.Add(25 * time.Hour)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &apiConfig{Name: "test"}
+			config.recordRenewalFailure(errors.New("renewal failed"), now)
+
+			config.recordCertificate(newTestCertificate(t, test.notBefore, now.Add(100*time.Hour)), test.obtained)
+
+			status := config.certificateStatus()
+			if !status.RenewAt.Equal(test.renewAt) {
+				t.Errorf("RenewAt = %s, want %s", status.RenewAt, test.renewAt)
+			}
+			if !status.NotAfter.Equal(now.Add(100 * time.Hour)) {
+				t.Errorf("NotAfter = %s, want %s", status.NotAfter, now.Add(100*time.Hour))
+			}
+			if status.RenewalError != "" {
+				t.Errorf("the renewal failure %q was kept for the new certificate", status.RenewalError)
+			}
+		})
+	}
+}
+
+func TestCertificateStatusString(t *testing.T) {
+
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		status   certificateStatus
+		expected string
+	}{
+		{"unknown", certificateStatus{}, ""},
+		{"valid", certificateStatus{NotAfter: now.Add(16*time.Hour + 48*time.Minute + 10*time.Second)}, "expires in 16h48m0s"},
+		{"expired", certificateStatus{NotAfter: now.Add(-time.Minute)}, "expired"},
+		{"renewal failed", certificateStatus{
+			NotAfter:     now.Add(5*time.Minute + 100*time.Millisecond),
+			RenewalError: "connection refused",
+			NextRetry:    now.Add(time.Minute + 100*time.Millisecond),
+		}, "renewal failed (retry in 1m0s): connection refused, expires in 5m0s"},
+	}
+
+	for _, test := range tests {
+		if actual := test.status.String(); actual != test.expected {
+			t.Errorf("%s: String() = %q, want %q", test.name, actual, test.expected)
+		}
+	}
+
+	config := &apiConfig{Name: "test"}
+	config.recordCertificate(newTestCertificate(t, now, now.Add(time.Hour)), now)
+	config.stopMonitor()
+	if status := config.certificateStatus().String(); status != "" {
+		t.Errorf("the status %q is kept after the monitor stopped", status)
+	}
+}
@@ -0,0 +1,335 @@
+package connector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/jcawley/kyma-app-connector/pkg/validate"
+)
+
+//PackageConfigEnv - json or yaml file with the package created for a compass application, see PackageConfig
+const PackageConfigEnv string = "APP_CONN_PACKAGE_CONFIG"
+
+const defaultPackageName string = "comm-demo-pkg"
+const defaultPackageDescription string = "Kyma Comm Demo App Package"
+
+//PackageConfig - the package created for a compass application, the runtime calls the apis of the package with the default instance auth
+type PackageConfig struct {
+	Name        string      `json:"name,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Auth        *AuthConfig `json:"defaultInstanceAuth,omitempty"`
+}
+
+//AuthConfig - basic or oauth client credentials, additional headers and query params and the csrf token endpoint
+type AuthConfig struct {
+	Basic       *BasicCredential  `json:"basic,omitempty"`
+	OAuth       *OAuthCredential  `json:"oauth,omitempty"`
+	Headers     map[string]Secret `json:"headers,omitempty"`
+	QueryParams map[string]Secret `json:"queryParams,omitempty"`
+	CSRF        *CSRFConfig       `json:"csrf,omitempty"`
+}
+
+//BasicCredential - username and password
+type BasicCredential struct {
+	Username string `json:"username"`
+	Password Secret `json:"password"`
+}
+
+//OAuthCredential - client credentials and the url of the token endpoint
+type OAuthCredential struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret Secret `json:"clientSecret"`
+	URL          string `json:"url"`
+}
+
+//CSRFConfig - the endpoint an X-CSRF-Token is fetched from before mutating calls
+type CSRFConfig struct {
+	TokenEndpointURL string `json:"tokenEndpointURL"`
+}
+
+//Secret - a value given as a string or as {"env": "NAME"}, {"file": "/path"} or {"value": "..."}
+//env and file are read when the package is created and not stored with the connection
+type Secret struct {
+	Value string `json:"value,omitempty"`
+	Env   string `json:"env,omitempty"`
+	File  string `json:"file,omitempty"`
+}
+
+//UnmarshalJSON - accepts a plain string as value
+func (secret *Secret) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*secret = Secret{Value: value}
+		return nil
+	}
+
+	type plainSecret Secret
+	return json.Unmarshal(data, (*plainSecret)(secret))
+}
+
+//Resolve - the value of the secret, empty values are an error
+func (secret Secret) Resolve() (string, error) {
+
+	var value string
+	switch {
+	case secret.Env != "":
+		value = os.Getenv(secret.Env)
+		if value == "" {
+			return "", fmt.Errorf("the environment variable %s is not set", secret.Env)
+		}
+	case secret.File != "":
+		data, err := ioutil.ReadFile(secret.File)
+		if err != nil {
+			return "", err
+		}
+		//mounted files usually end with a new line
+		value = strings.TrimRight(string(data), "\r\n")
+	default:
+		value = secret.Value
+	}
+
+	if value == "" {
+		return "", errors.New("the secret is empty")
+	}
+	return value, nil
+}
+
+//the package configured by the file of APP_CONN_PACKAGE_CONFIG overridden by the fields set in the request,
+//without configured auth the package has no default instance auth, relative token urls are resolved against the hostURL
+func packageConfig(requested *PackageConfig, hostURL string) (PackageConfig, error) {
+
+	pkg := PackageConfig{}
+	if path := os.Getenv(PackageConfigEnv); path != "" {
+		var err error
+		if pkg, err = ReadPackageConfig(path); err != nil {
+			return PackageConfig{}, err
+		}
+	}
+
+	if requested != nil {
+		if requested.Name != "" {
+			pkg.Name = requested.Name
+		}
+		if requested.Description != "" {
+			pkg.Description = requested.Description
+		}
+		if requested.Auth != nil {
+			pkg.Auth = requested.Auth
+		}
+	}
+
+	if pkg.Name == "" {
+		pkg.Name = defaultPackageName
+	}
+	if pkg.Description == "" {
+		pkg.Description = defaultPackageDescription
+	}
+	if pkg.Auth != nil {
+		pkg.Auth = pkg.Auth.withBaseURL(hostURL)
+	}
+	return pkg, nil
+}
+
+//a copy with the token urls relative to the server of the api resolved against the base url
+func (auth AuthConfig) withBaseURL(base string) *AuthConfig {
+	if auth.OAuth != nil && strings.HasPrefix(auth.OAuth.URL, "/") {
+		oauth := *auth.OAuth
+		oauth.URL = resolveURL(base, oauth.URL)
+		auth.OAuth = &oauth
+	}
+	if auth.CSRF != nil && strings.HasPrefix(auth.CSRF.TokenEndpointURL, "/") {
+		auth.CSRF = &CSRFConfig{TokenEndpointURL: resolveURL(base, auth.CSRF.TokenEndpointURL)}
+	}
+	return &auth
+}
+
+//the reference resolved against the base url, the reference is kept if the base is not a url
+func resolveURL(base string, reference string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return reference
+	}
+	referenceURL, err := url.Parse(reference)
+	if err != nil {
+		return reference
+	}
+	return baseURL.ResolveReference(referenceURL).String()
+}
+
+//ConfiguredAuth - the default instance auth of the package config file of APP_CONN_PACKAGE_CONFIG, nil if none is configured
+func ConfiguredAuth() (*AuthConfig, error) {
+	path := os.Getenv(PackageConfigEnv)
+	if path == "" {
+		return nil, nil
+	}
+
+	pkg, err := ReadPackageConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.Auth, nil
+}
+
+//ReadPackageConfig - reads a package config from a json or yaml file
+func ReadPackageConfig(path string) (PackageConfig, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PackageConfig{}, err
+	}
+
+	//yaml is converted to json to reuse the json field names
+	value, err := validate.Parse(data)
+	if err == nil {
+		data, err = json.Marshal(value)
+	}
+
+	var pkg PackageConfig
+	if err == nil {
+		err = json.Unmarshal(data, &pkg)
+	}
+	if err != nil {
+		return PackageConfig{}, fmt.Errorf("could not read the package config %s: %s", path, err)
+	}
+	return pkg, nil
+}
+
+//the PackageCreateInput of the director, secrets are resolved
+func (pkg PackageConfig) input() (map[string]interface{}, error) {
+
+	input := map[string]interface{}{
+		"name":        pkg.Name,
+		"description": pkg.Description,
+	}
+
+	if pkg.Auth == nil {
+		return input, nil
+	}
+
+	auth, err := pkg.Auth.input()
+	if err != nil {
+		return nil, fmt.Errorf("default instance auth of package %s: %s", pkg.Name, err)
+	}
+	input["defaultInstanceAuth"] = auth
+	return input, nil
+}
+
+func (auth AuthConfig) input() (map[string]interface{}, error) {
+
+	if auth.Basic != nil && auth.OAuth != nil {
+		return nil, errors.New("basic and oauth cannot be combined")
+	}
+
+	input := map[string]interface{}{}
+
+	switch {
+	case auth.Basic != nil:
+		if auth.Basic.Username == "" {
+			return nil, errors.New("basic.username is required")
+		}
+		password, err := auth.Basic.Password.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("basic.password: %s", err)
+		}
+		input["credential"] = map[string]interface{}{
+			"basic": map[string]interface{}{"username": auth.Basic.Username, "password": password},
+		}
+	case auth.OAuth != nil:
+		if auth.OAuth.ClientID == "" || auth.OAuth.URL == "" {
+			return nil, errors.New("oauth.clientId and oauth.url are required")
+		}
+		clientSecret, err := auth.OAuth.ClientSecret.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("oauth.clientSecret: %s", err)
+		}
+		input["credential"] = map[string]interface{}{
+			"oauth": map[string]interface{}{"clientId": auth.OAuth.ClientID, "clientSecret": clientSecret, "url": auth.OAuth.URL},
+		}
+	}
+
+	if len(auth.Headers) > 0 {
+		headers, err := resolveParams(auth.Headers)
+		if err != nil {
+			return nil, fmt.Errorf("headers.%s", err)
+		}
+		input["additionalHeaders"] = headers
+	}
+
+	if len(auth.QueryParams) > 0 {
+		queryParams, err := resolveParams(auth.QueryParams)
+		if err != nil {
+			return nil, fmt.Errorf("queryParams.%s", err)
+		}
+		input["additionalQueryParams"] = queryParams
+	}
+
+	if auth.CSRF != nil {
+		if auth.CSRF.TokenEndpointURL == "" {
+			return nil, errors.New("csrf.tokenEndpointURL is required")
+		}
+		input["requestAuth"] = map[string]interface{}{
+			"csrf": map[string]interface{}{"tokenEndpointURL": auth.CSRF.TokenEndpointURL},
+		}
+	}
+
+	if len(input) == 0 {
+		return nil, errors.New("no credential, headers, query params or csrf configured")
+	}
+	return input, nil
+}
+
+//the credentials of a kyma service and the headers and query params as its request parameters, nil if not configured
+//the credentials and parameters have the same form as the ones of a compass package, the csrf token endpoint is
+//part of the credentials
+func (auth AuthConfig) restAuth() (map[string]interface{}, map[string]interface{}, error) {
+
+	input, err := auth.input()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var credentials map[string]interface{}
+	if credential, ok := input["credential"]; ok {
+		credentials = credential.(map[string]interface{})
+	}
+
+	if auth.CSRF != nil {
+		if credentials == nil {
+			return nil, nil, errors.New("csrf requires basic or oauth credentials for a kyma service")
+		}
+		for _, credential := range credentials {
+			credential.(map[string]interface{})["csrfInfo"] = map[string]interface{}{"tokenEndpointURL": auth.CSRF.TokenEndpointURL}
+		}
+	}
+
+	requestParameters := map[string]interface{}{}
+	if headers, ok := input["additionalHeaders"]; ok {
+		requestParameters["headers"] = headers
+	}
+	if queryParams, ok := input["additionalQueryParams"]; ok {
+		requestParameters["queryParameters"] = queryParams
+	}
+	if len(requestParameters) == 0 {
+		requestParameters = nil
+	}
+
+	return credentials, requestParameters, nil
+}
+
+//compass expects the values of headers and query params as lists
+func resolveParams(params map[string]Secret) (map[string][]string, error) {
+	resolved := map[string][]string{}
+	for name, secret := range params {
+		value, err := secret.Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		resolved[name] = []string{value}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,88 @@
+package connector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackageConfigWithoutAuth(t *testing.T) {
+
+	os.Unsetenv(PackageConfigEnv)
+
+	//the demo credentials of the sample api must not become the auth of the package
+	defer func(auth func() *AuthConfig) { sampleAuth = auth }(sampleAuth)
+	sampleAuth = func() *AuthConfig {
+		return &AuthConfig{Basic: &BasicCredential{Username: "demo", Password: Secret{Value: "demo"}}}
+	}
+
+	pkg, err := packageConfig(nil, "http://localhost:8000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name != defaultPackageName || pkg.Description != defaultPackageDescription {
+		t.Errorf("package = %s %q, want the defaults", pkg.Name, pkg.Description)
+	}
+	if pkg.Auth != nil {
+		t.Errorf("the package got the default instance auth %+v without a configured one", pkg.Auth)
+	}
+
+	input, err := pkg.input()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := input["defaultInstanceAuth"]; ok {
+		t.Errorf("the package input has a default instance auth: %v", input)
+	}
+}
+
+func TestPackageConfigRequestOverridesFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "package-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "package.yaml")
+	config := []byte(`
+name: orders-pkg
+description: Orders of the demo app
+defaultInstanceAuth:
+  basic:
+    username: orders
+    password: {env: TEST_ORDERS_PASSWORD}
+`)
+	if err := ioutil.WriteFile(path, config, 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(PackageConfigEnv, path)
+	defer os.Unsetenv(PackageConfigEnv)
+
+	pkg, err := packageConfig(nil, "http://localhost:8000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name != "orders-pkg" || pkg.Auth == nil || pkg.Auth.Basic == nil || pkg.Auth.Basic.Password.Env != "TEST_ORDERS_PASSWORD" {
+		t.Errorf("package = %+v, want the one of the file", pkg)
+	}
+
+	requested := &PackageConfig{
+		Name: "other-pkg",
+		Auth: &AuthConfig{OAuth: &OAuthCredential{ClientID: "client", ClientSecret: Secret{Value: "secret"}, URL: "/oauth/token"}},
+	}
+	pkg, err = packageConfig(requested, "http://localhost:8000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name != "other-pkg" || pkg.Description != "Orders of the demo app" {
+		t.Errorf("package = %s %q, want the requested name and the description of the file", pkg.Name, pkg.Description)
+	}
+	if pkg.Auth.Basic != nil || pkg.Auth.OAuth == nil || pkg.Auth.OAuth.URL != "http://localhost:8000/oauth/token" {
+		t.Errorf("auth = %+v, want the requested oauth client with the token url resolved against the host url", pkg.Auth)
+	}
+	if requested.Auth.OAuth.URL != "/oauth/token" {
+		t.Errorf("the requested auth was changed to %+v", requested.Auth.OAuth)
+	}
+}
@@ -0,0 +1,210 @@
+package connector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//EventModeEnv - default event mode of all connections: legacy, structured or binary
+const EventModeEnv string = "APP_CONN_EVENT_MODE"
+
+//EventTypePrefixEnv - prefix of the cloud event types, sap.kyma.custom by default
+const EventTypePrefixEnv string = "APP_CONN_EVENT_TYPE_PREFIX"
+
+//EventModeLegacy - the event-type / event-type-version json format of the v1 events endpoint
+const EventModeLegacy string = "legacy"
+
+//EventModeStructured - a CloudEvents 1.0 json document
+const EventModeStructured string = "structured"
+
+//EventModeBinary - the data as body, the CloudEvents 1.0 attributes as ce-* headers
+const EventModeBinary string = "binary"
+
+const defaultEventTypePrefix string = "sap.kyma.custom"
+
+const cloudEventsSpecVersion string = "1.0"
+const cloudEventsContentType string = "application/cloudevents+json"
+
+//events endpoint of the legacy format, cloud events are sent to the events endpoint of the application
+const legacyEventsPath string = "/v1/events"
+const cloudEventsPath string = "/events"
+
+//an event to publish, the attributes are shared by all modes
+type event struct {
+	ID      string
+	Time    time.Time
+	Type    string
+	Version string
+	//the application sending the event
+	Source string
+	Data   json.RawMessage
+}
+
+//eventPublisher - builds the request publishing an event in one of the event modes
+type eventPublisher interface {
+	request(eventURL string, e event) (*http.Request, error)
+}
+
+type legacyPublisher struct{}
+
+type structuredPublisher struct{}
+
+type binaryPublisher struct{}
+
+//the publisher of the mode, an empty mode is the legacy format
+func newPublisher(mode string) (eventPublisher, error) {
+	switch mode {
+	case EventModeLegacy, "":
+		return legacyPublisher{}, nil
+	case EventModeStructured:
+		return structuredPublisher{}, nil
+	case EventModeBinary:
+		return binaryPublisher{}, nil
+	}
+	return nil, fmt.Errorf("unknown event mode %q, use %s, %s or %s", mode, EventModeLegacy, EventModeStructured, EventModeBinary)
+}
+
+func (legacyPublisher) request(eventURL string, e event) (*http.Request, error) {
+
+	body, err := json.Marshal(map[string]interface{}{
+		"event-type":         e.Type,
+		"event-type-version": e.Version,
+		"event-id":           e.ID,
+		"event-time":         e.Time.Format(time.RFC3339),
+		"data":               e.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", eventURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func (structuredPublisher) request(eventURL string, e event) (*http.Request, error) {
+
+	body, err := json.Marshal(map[string]interface{}{
+		"specversion":      cloudEventsSpecVersion,
+		"id":               e.ID,
+		"source":           e.Source,
+		"type":             cloudEventType(e),
+		"time":             e.Time.Format(time.RFC3339),
+		"eventtypeversion": e.Version,
+		"datacontenttype":  "application/json",
+		"data":             e.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", eventURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", cloudEventsContentType)
+	return req, nil
+}
+
+func (binaryPublisher) request(eventURL string, e event) (*http.Request, error) {
+
+	req, err := http.NewRequest("POST", eventURL, bytes.NewBuffer(e.Data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", cloudEventsSpecVersion)
+	req.Header.Set("ce-id", e.ID)
+	req.Header.Set("ce-source", e.Source)
+	req.Header.Set("ce-type", cloudEventType(e))
+	req.Header.Set("ce-time", e.Time.Format(time.RFC3339))
+	req.Header.Set("ce-eventtypeversion", e.Version)
+	return req, nil
+}
+
+//the SAP style type <prefix>.<application>.<event type>.<version>, e.g. sap.kyma.custom.demoapp.orderCreated.v1
+//types already starting with the prefix are kept
+func cloudEventType(e event) string {
+
+	prefix := os.Getenv(EventTypePrefixEnv)
+	if prefix == "" {
+		prefix = defaultEventTypePrefix
+	}
+	if strings.HasPrefix(e.Type, prefix+".") {
+		return e.Type
+	}
+
+	return strings.Join([]string{prefix, cleanSegment(e.Source), e.Type, e.Version}, ".")
+}
+
+//kyma only keeps letters and digits of the application name in event types
+func cleanSegment(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+//the application of an events url of the form <gateway>/<application>/v1/events, empty if it has another form
+func applicationName(eventURL string) string {
+	parsed, err := url.Parse(eventURL)
+	if err != nil || !strings.HasSuffix(parsed.Path, legacyEventsPath) {
+		return ""
+	}
+
+	application := strings.TrimSuffix(parsed.Path, legacyEventsPath)
+	return application[strings.LastIndex(application, "/")+1:]
+}
+
+//the cloud events endpoint next to the legacy one, <gateway>/<application>/events
+func cloudEventsURL(eventURL string) string {
+	if !strings.HasSuffix(eventURL, legacyEventsPath) {
+		return eventURL
+	}
+	return strings.TrimSuffix(eventURL, legacyEventsPath) + cloudEventsPath
+}
+
+//CheckEventMode - validates an event mode, empty is allowed and leaves the choice to APP_CONN_EVENT_MODE
+func CheckEventMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	_, err := newPublisher(mode)
+	return err
+}
+
+//the mode of the connection, else the one of APP_CONN_EVENT_MODE, else legacy
+func (config *apiConfig) eventMode() string {
+	if config.EventMode != "" {
+		return config.EventMode
+	}
+	if mode := os.Getenv(EventModeEnv); mode != "" {
+		return mode
+	}
+	return EventModeLegacy
+}
+
+//the url events of the mode are published to, the configured url of the connection replaces the one of the system
+func (config *apiConfig) eventURL(mode string) string {
+	if config.EventURL != "" {
+		return config.EventURL
+	}
+
+	eventURL := config.kc.getEventURL()
+	if mode == EventModeLegacy || eventURL == "" {
+		return eventURL
+	}
+	return cloudEventsURL(eventURL)
+}
@@ -0,0 +1,219 @@
+package connector_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+//a request received by the event recorder
+type recordedEvent struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+//an events endpoint recording the requests
+func newEventRecorder(t *testing.T) (*httptest.Server, func() []recordedEvent) {
+
+	var mu sync.Mutex
+	var events []recordedEvent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if r.Method != "POST" {
+			t.Errorf("the event was sent with %s", r.Method)
+		}
+
+		mu.Lock()
+		events = append(events, recordedEvent{r.URL.Path, r.Header, body})
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return server, func() []recordedEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedEvent{}, events...)
+	}
+}
+
+//the event sent in the mode to the recorder
+func publishRecorded(t *testing.T, name string, mode string, eventURL string, received func() []recordedEvent) recordedEvent {
+
+	if _, err := connector.SetConnectionEventMode(name, connector.EventModeRequest{Mode: mode, URL: eventURL}); err != nil {
+		t.Fatal(err)
+	}
+
+	before := len(received())
+	resp, err := connector.SendEvent(name, "order.created", "v1", json.RawMessage(`{"orderCode":"1"}`))
+	if err != nil {
+		t.Fatalf("SendEvent: %s", err)
+	}
+	if string(resp) != "event published with status 204" {
+		t.Errorf("SendEvent = %s, want the status of the empty response", resp)
+	}
+
+	events := received()
+	if len(events) != before+1 {
+		t.Fatalf("%d events received, want 1", len(events)-before)
+	}
+	return events[len(events)-1]
+}
+
+func checkEventTime(t *testing.T, value string) {
+	eventTime, err := time.Parse(time.RFC3339, value)
+	if err != nil || time.Since(eventTime) > time.Minute {
+		t.Errorf("the event time %q is not the current RFC 3339 time", value)
+	}
+}
+
+func TestPublishEventModes(t *testing.T) {
+
+	system := newKymaSystem(t)
+	defer system.close()
+
+	newTestStore()
+	connectTestSystem(t, "events", system)
+
+	recorder, received := newEventRecorder(t)
+	defer recorder.Close()
+
+	//the application of the events url of the fake kyma
+	const source string = "test-app"
+	const cloudEventType string = "sap.kyma.custom.testapp.order.created.v1"
+
+	t.Run("legacy", func(t *testing.T) {
+		recorded := publishRecorded(t, "events", connector.EventModeLegacy, recorder.URL+"/test-app/v1/events", received)
+
+		if recorded.path != "/test-app/v1/events" || recorded.header.Get("Content-Type") != "application/json" {
+			t.Errorf("sent to %s as %s, want the legacy endpoint as application/json", recorded.path, recorded.header.Get("Content-Type"))
+		}
+
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(recorded.body, &body); err != nil {
+			t.Fatal(err)
+		}
+		var eventType, version, id, eventTime string
+		json.Unmarshal(body["event-type"], &eventType)
+		json.Unmarshal(body["event-type-version"], &version)
+		json.Unmarshal(body["event-id"], &id)
+		json.Unmarshal(body["event-time"], &eventTime)
+
+		if eventType != "order.created" || version != "v1" || id == "" {
+			t.Errorf("event-type %q, event-type-version %q and event-id %q, want order.created, v1 and an id", eventType, version, id)
+		}
+		checkEventTime(t, eventTime)
+		if string(body["data"]) != `{"orderCode":"1"}` {
+			t.Errorf("data = %s", body["data"])
+		}
+	})
+
+	t.Run("structured", func(t *testing.T) {
+		recorded := publishRecorded(t, "events", connector.EventModeStructured, recorder.URL+"/test-app/events", received)
+
+		if recorded.path != "/test-app/events" || recorded.header.Get("Content-Type") != "application/cloudevents+json" {
+			t.Errorf("sent to %s as %s, want the cloud events endpoint as application/cloudevents+json", recorded.path, recorded.header.Get("Content-Type"))
+		}
+		if recorded.header.Get("ce-type") != "" {
+			t.Errorf("the structured event has the binary header ce-type %q", recorded.header.Get("ce-type"))
+		}
+
+		var body struct {
+			SpecVersion      string          `json:"specversion"`
+			ID               string          `json:"id"`
+			Source           string          `json:"source"`
+			Type             string          `json:"type"`
+			Time             string          `json:"time"`
+			EventTypeVersion string          `json:"eventtypeversion"`
+			DataContentType  string          `json:"datacontenttype"`
+			Data             json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(recorded.body, &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.SpecVersion != "1.0" || body.ID == "" || body.Source != source || body.Type != cloudEventType {
+			t.Errorf("specversion %q, id %q, source %q and type %q, want 1.0, an id, %s and %s", body.SpecVersion, body.ID, body.Source, body.Type, source, cloudEventType)
+		}
+		if body.EventTypeVersion != "v1" || body.DataContentType != "application/json" || string(body.Data) != `{"orderCode":"1"}` {
+			t.Errorf("eventtypeversion %q, datacontenttype %q and data %s", body.EventTypeVersion, body.DataContentType, body.Data)
+		}
+		checkEventTime(t, body.Time)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		recorded := publishRecorded(t, "events", connector.EventModeBinary, recorder.URL+"/test-app/events", received)
+
+		if recorded.path != "/test-app/events" || recorded.header.Get("Content-Type") != "application/json" {
+			t.Errorf("sent to %s as %s, want the cloud events endpoint as application/json", recorded.path, recorded.header.Get("Content-Type"))
+		}
+
+		expected := map[string]string{
+			"ce-specversion":      "1.0",
+			"ce-source":           source,
+			"ce-type":             cloudEventType,
+			"ce-eventtypeversion": "v1",
+		}
+		for header, value := range expected {
+			if actual := recorded.header.Get(header); actual != value {
+				t.Errorf("%s = %q, want %q", header, actual, value)
+			}
+		}
+		if recorded.header.Get("ce-id") == "" {
+			t.Error("the event has no ce-id")
+		}
+		checkEventTime(t, recorded.header.Get("ce-time"))
+
+		//the body is the data only
+		if string(recorded.body) != `{"orderCode":"1"}` {
+			t.Errorf("body = %s, want the data", recorded.body)
+		}
+	})
+
+	//every event gets its own id
+	ids := map[string]bool{}
+	for _, recorded := range received() {
+		var body struct {
+			LegacyID string `json:"event-id"`
+			ID       string `json:"id"`
+		}
+		json.Unmarshal(recorded.body, &body)
+		ids[body.LegacyID+body.ID+recorded.header.Get("ce-id")] = true
+	}
+	if len(ids) != 3 {
+		t.Errorf("the 3 events have %d different ids", len(ids))
+	}
+}
+
+//without a configured url the cloud events are sent to the events endpoint next to the legacy one of the system
+func TestPublishEventModesToSystem(t *testing.T) {
+
+	system := newKymaSystem(t)
+	defer system.close()
+
+	newTestStore()
+	connectTestSystem(t, "system-events", system)
+
+	for _, mode := range []string{connector.EventModeLegacy, connector.EventModeStructured, connector.EventModeBinary} {
+		if _, err := connector.PublishEvent("system-events", mode, "order.created", "v1", json.RawMessage(`{"orderCode":"1"}`)); err != nil {
+			t.Errorf("PublishEvent as %s: %s", mode, err)
+		}
+	}
+	if events := system.events(); len(events) != 3 {
+		t.Errorf("%d events received, want 3", len(events))
+	}
+
+	if _, err := connector.PublishEvent("system-events", "batched", "order.created", "v1", json.RawMessage(`{}`)); err == nil {
+		t.Error("PublishEvent accepted the unknown mode batched")
+	}
+}
@@ -0,0 +1,283 @@
+package connector
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+)
+
+var errNoRegistrationID = errors.New("No registration id provided")
+
+//ListRegistrations - lists the apis and events the system has registered for the application
+func ListRegistrations(w http.ResponseWriter, r *http.Request) {
+	log.Println("ListRegistrations")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
+		return
+	}
+
+	config.mu.RLock()
+	registrations, err := config.listRegistrations()
+	config.mu.RUnlock()
+
+	if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnJSON(registrations, w)
+	}
+}
+
+//UpdateAPISpec - replaces the registered api with the sample api spec, the body is the hostURL
+//an invalid spec is refused unless ?force=true
+func UpdateAPISpec(w http.ResponseWriter, r *http.Request) {
+	log.Println("UpdateAPISpec")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
+		return
+	}
+
+	defer r.Body.Close()
+	hostURL, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		utils.ReturnError("Could not read the body text", w)
+		return
+	}
+
+	config.mu.Lock()
+	resp, err := config.updateAPISpec(mux.Vars(r)["id"], nil, hostURL, forced(r))
+	config.mu.Unlock()
+
+	returnResult(resp, err, w)
+}
+
+//UpdateEventSpec - replaces the registered events with the sample event spec
+//an invalid spec is refused unless ?force=true
+func UpdateEventSpec(w http.ResponseWriter, r *http.Request) {
+	log.Println("UpdateEventSpec")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
+		return
+	}
+
+	config.mu.Lock()
+	resp, err := config.updateEventSpec(mux.Vars(r)["id"], nil, forced(r))
+	config.mu.Unlock()
+
+	returnResult(resp, err, w)
+}
+
+//DeleteAPISpec - removes a registered api
+func DeleteAPISpec(w http.ResponseWriter, r *http.Request) {
+	log.Println("DeleteAPISpec")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	config.mu.Lock()
+	err := config.deleteAPISpec(id)
+	config.mu.Unlock()
+
+	if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnSuccess("API "+id+" has been deleted", w)
+	}
+}
+
+//DeleteEventSpec - removes registered events
+func DeleteEventSpec(w http.ResponseWriter, r *http.Request) {
+	log.Println("DeleteEventSpec")
+
+	config := getConnection(ConnectionName(r))
+
+	if config == nil {
+		utils.ReturnError(errNoTLSConnection.Error(), w)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	config.mu.Lock()
+	err := config.deleteEventSpec(id)
+	config.mu.Unlock()
+
+	if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnSuccess("Events "+id+" have been deleted", w)
+	}
+}
+
+//replaces the api registered with the id, the sample spec is used if none is given
+//an invalid spec is refused unless forced
+func (config *apiConfig) updateAPISpec(id string, APISpec []byte, hostURL []byte, force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	if id == "" {
+		return nil, errNoRegistrationID
+	}
+
+	if APISpec == nil {
+		var err error
+		if APISpec, err = config.sampleAPISpec(); err != nil {
+			return nil, err
+		}
+	}
+
+	hostURL = defaultHostURL(hostURL)
+	if err := config.checkAPISpec(APISpec, hostURL, force); err != nil {
+		return nil, err
+	}
+
+	resp, err := config.kc.updateAPISpec(config.HTTPTLSClient, id, APISpec, hostURL)
+	if err != nil {
+		return nil, err
+	}
+
+	config.persist()
+	return resp, nil
+}
+
+//replaces the events registered with the id, the sample spec is used if none is given
+//an invalid spec is refused unless forced
+func (config *apiConfig) updateEventSpec(id string, EventSpec []byte, force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	if id == "" {
+		return nil, errNoRegistrationID
+	}
+
+	if EventSpec == nil {
+		var err error
+		if EventSpec, err = config.sampleEventSpec(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.checkSpec(RegistrationKindEvents, EventSpec, force); err != nil {
+		return nil, err
+	}
+
+	resp, err := config.kc.updateEventSpec(config.HTTPTLSClient, id, EventSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	config.persist()
+	return resp, nil
+}
+
+func (config *apiConfig) deleteAPISpec(id string) error {
+
+	if config.HTTPTLSClient == nil {
+		return errNoTLSConnection
+	}
+
+	if id == "" {
+		return errNoRegistrationID
+	}
+
+	if err := config.kc.deleteAPISpec(config.HTTPTLSClient, id); err != nil {
+		return err
+	}
+
+	config.persist()
+	return nil
+}
+
+func (config *apiConfig) deleteEventSpec(id string) error {
+
+	if config.HTTPTLSClient == nil {
+		return errNoTLSConnection
+	}
+
+	if id == "" {
+		return errNoRegistrationID
+	}
+
+	if err := config.kc.deleteEventSpec(config.HTTPTLSClient, id); err != nil {
+		return err
+	}
+
+	config.persist()
+	return nil
+}
+
+//the registrations of the system, the ones created or updated by this app are marked as tracked
+func (config *apiConfig) listRegistrations() ([]RegistrationDetails, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	registrations, err := config.kc.listRegistrations(config.HTTPTLSClient)
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := map[string]bool{}
+	for _, registration := range config.registrations() {
+		tracked[registration.ID] = true
+	}
+
+	for i := range registrations {
+		registrations[i].Tracked = tracked[registrations[i].ID]
+	}
+
+	return registrations, nil
+}
+
+//the registrations created or updated by this app
+func (config *apiConfig) registrations() []Registration {
+	if config.kc == nil {
+		return nil
+	}
+	return config.kc.getRegistrations()
+}
+
+//records the registration, an existing one keeps its position
+func trackRegistration(registrations []Registration, id string, kind string) []Registration {
+	for i := range registrations {
+		if registrations[i].ID == id {
+			registrations[i].Kind = kind
+			return registrations
+		}
+	}
+	return append(registrations, Registration{ID: id, Kind: kind})
+}
+
+func untrackRegistration(registrations []Registration, id string) []Registration {
+	remaining := registrations[:0]
+	for _, registration := range registrations {
+		if registration.ID != id {
+			remaining = append(remaining, registration)
+		}
+	}
+	return remaining
+}
@@ -9,7 +9,9 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
@@ -21,16 +23,22 @@ func init() {
 func (KymaConn *restConnector) callTokenURL(oneTimeTokenURL string) ([]byte, error) {
 	log.Println("CallTokenURL via rest...")
 
-	resp, err := http.Get(oneTimeTokenURL)
+	resp, err := publicClient.Get(oneTimeTokenURL)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
+	//a used or expired token is refused, the error of the connector is the body
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("calling the token url failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
 	err = json.Unmarshal(respBody, KymaConn)
 
 	if err != nil {
@@ -41,15 +49,16 @@ func (KymaConn *restConnector) callTokenURL(oneTimeTokenURL string) ([]byte, err
 }
 
 //SendCSRToKyma -
-func (KymaConn *restConnector) sendCSRToKyma(csr []byte) ([]byte, error) {
+func (KymaConn *restConnector) sendCSRToKyma(csr []byte) (*csrConnectResponse, error) {
 	log.Println("SendCSRToKyma via rest...")
 
 	csrJSON := []byte(fmt.Sprintf("{\"csr\":\"%s\"}", base64.StdEncoding.EncodeToString(csr)))
 
-	resp, err := http.Post(KymaConn.CsrURL, "application/json", bytes.NewBuffer(csrJSON))
+	resp, err := publicClient.Post(KymaConn.CsrURL, "application/json", bytes.NewBuffer(csrJSON))
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 
@@ -57,6 +66,10 @@ func (KymaConn *restConnector) sendCSRToKyma(csr []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("sending the csr failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
 	csrRespData := &csrConnectResponse{}
 	err = json.Unmarshal([]byte(respBody), csrRespData)
 
@@ -64,11 +77,11 @@ func (KymaConn *restConnector) sendCSRToKyma(csr []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(csrRespData.Crt), nil
+	return csrRespData, nil
 }
 
 //GetAppInfo - STEP 3
-func (KymaConn *restConnector) getAppInfo(TLSClient *http.Client) ([]byte, error) {
+func (KymaConn *restConnector) getAppInfo(TLSClient *http.Client, pkg PackageConfig) ([]byte, error) {
 	log.Println("GetAppInfo via rest")
 
 	//MetadataURL
@@ -76,13 +89,21 @@ func (KymaConn *restConnector) getAppInfo(TLSClient *http.Client) ([]byte, error
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(respBody, KymaConn)
+	//a revoked or expired certificate is refused, the connection must not count as connected
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("getting the app info failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, KymaConn); err != nil {
+		return nil, err
+	}
 
 	return respBody, nil
 }
@@ -95,50 +116,370 @@ func (KymaConn *restConnector) sendAPISpec(TLSClient *http.Client, APISpec []byt
 		return nil, errors.New("no MetadataURL exists")
 	}
 
-	json, err := sjson.Set(string(APISpec), "api.targetUrl", string(hostURL))
+	json, err := setTargetURL(string(APISpec), string(hostURL))
+	if err != nil {
+		return nil, err
+	}
 
 	log.Println("...........")
-	log.Println(json)
+	log.Println(withoutCredentials(json))
+
+	respBody, _, err := KymaConn.createService(TLSClient, []byte(json), RegistrationKindAPI)
+	return respBody, err
+}
+
+//the token urls of the credentials of a service
+var tokenURLPaths = []string{
+	"api.credentials.oauth.url",
+	"api.credentials.oauth.csrfInfo.tokenEndpointURL",
+	"api.credentials.basic.csrfInfo.tokenEndpointURL",
+}
+
+//sets the target url of the api, token urls relative to the server are resolved against it
+func setTargetURL(service string, targetURL string) (string, error) {
+
+	service, err := sjson.Set(service, "api.targetUrl", targetURL)
+	for _, path := range tokenURLPaths {
+		if err != nil {
+			return "", err
+		}
+		if tokenURL := gjson.Get(service, path).String(); strings.HasPrefix(tokenURL, "/") {
+			service, err = sjson.Set(service, path, resolveURL(targetURL, tokenURL))
+		}
+	}
+	return service, err
+}
+
+//the service as logged, credentials are left out
+func withoutCredentials(service string) string {
+	if logged, err := sjson.Delete(service, "api.credentials"); err == nil {
+		return logged
+	}
+	return service
+}
+
+//SendEventSpec -
+func (KymaConn *restConnector) sendEventSpec(TLSClient *http.Client, EventSpec []byte) ([]byte, error) {
+	log.Println("SendEventSpec via rest")
+
+	respBody, _, err := KymaConn.createService(TLSClient, EventSpec, RegistrationKindEvents)
+	return respBody, err
+}
+
+//posts the service to the metadata url and tracks it
+func (KymaConn *restConnector) createService(TLSClient *http.Client, service []byte, kind string) ([]byte, string, error) {
+
+	if KymaConn.Urls.MetadataURL == "" {
+		return nil, "", errors.New("no MetadataURL exists")
+	}
+
+	resp, err := TLSClient.Post(KymaConn.Urls.MetadataURL, "application/json", bytes.NewBuffer(service))
+
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
 
+	respBody, err := ioutil.ReadAll(resp.Body)
+	log.Println(string(respBody))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, "", fmt.Errorf("creating the service failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return respBody, KymaConn.addServiceID(respBody, kind), nil
+}
+
+//SaveSpec - creates or replaces the service, the content hash is stored in its labels
+func (KymaConn *restConnector) saveSpec(TLSClient *http.Client, id string, spec SpecDeclaration, contentHash string) (string, error) {
+	log.Printf("SaveSpec %s via rest", spec.Name)
+
+	service, err := sjson.Set(string(spec.document()), "name", spec.Name)
+	if err == nil && spec.Description != "" {
+		service, err = sjson.Set(service, "description", spec.Description)
+	}
+	for key, value := range spec.Labels {
+		if err == nil {
+			service, err = sjson.Set(service, "labels."+escapePath(key), value)
+		}
+	}
+	if err == nil {
+		service, err = sjson.Set(service, "labels."+contentHashLabel, contentHash)
+	}
+	if err == nil && spec.Kind == RegistrationKindAPI {
+		service, err = setTargetURL(service, spec.TargetURL)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if id == "" {
+		_, id, err = KymaConn.createService(TLSClient, []byte(service), spec.Kind)
+		return id, err
+	}
+
+	_, err = KymaConn.updateService(TLSClient, id, []byte(service), spec.Kind)
+	return id, err
+}
+
+//UpdateAPISpec - replaces the service with the id via the metadata url
+func (KymaConn *restConnector) updateAPISpec(TLSClient *http.Client, id string, APISpec []byte, hostURL []byte) ([]byte, error) {
+	log.Println("UpdateAPISpec via rest")
+
+	json, err := setTargetURL(string(APISpec), string(hostURL))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := TLSClient.Post(KymaConn.Urls.MetadataURL, "application/json", bytes.NewBuffer([]byte(json)))
+	return KymaConn.updateService(TLSClient, id, []byte(json), RegistrationKindAPI)
+}
+
+//UpdateEventSpec - replaces the service with the id via the metadata url
+func (KymaConn *restConnector) updateEventSpec(TLSClient *http.Client, id string, EventSpec []byte) ([]byte, error) {
+	log.Println("UpdateEventSpec via rest")
 
+	return KymaConn.updateService(TLSClient, id, EventSpec, RegistrationKindEvents)
+}
+
+func (KymaConn *restConnector) updateService(TLSClient *http.Client, id string, service []byte, kind string) ([]byte, error) {
+
+	if KymaConn.Urls.MetadataURL == "" {
+		return nil, errors.New("no MetadataURL exists")
+	}
+
+	req, err := http.NewRequest(http.MethodPut, KymaConn.Urls.MetadataURL+"/"+id, bytes.NewBuffer(service))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := TLSClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
-	log.Println(string(respBody))
 	if err != nil {
 		return nil, err
 	}
 
-	return respBody, nil
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("updating service %s failed with status %d: %s", id, resp.StatusCode, string(respBody))
+	}
+
+	//services created elsewhere are tracked once they have been updated
+	KymaConn.Registrations = trackRegistration(KymaConn.Registrations, id, kind)
+
+	return []byte(fmt.Sprintf("{\"id\":\"%s\"}", id)), nil
 }
 
-//SendEventSpec -
-func (KymaConn *restConnector) sendEventSpec(TLSClient *http.Client, EventSpec []byte) ([]byte, error) {
-	log.Println("SendEventSpec via rest")
+//DeleteAPISpec - deletes the service with the id via the metadata url
+func (KymaConn *restConnector) deleteAPISpec(TLSClient *http.Client, id string) error {
+	log.Println("DeleteAPISpec via rest")
+
+	return KymaConn.deleteService(TLSClient, id)
+}
+
+//DeleteEventSpec - deletes the service with the id via the metadata url
+func (KymaConn *restConnector) deleteEventSpec(TLSClient *http.Client, id string) error {
+	log.Println("DeleteEventSpec via rest")
+
+	return KymaConn.deleteService(TLSClient, id)
+}
+
+//a service that no longer exists counts as deleted
+func (KymaConn *restConnector) deleteService(TLSClient *http.Client, id string) error {
+
+	if KymaConn.Urls.MetadataURL == "" {
+		return errors.New("no MetadataURL exists")
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, KymaConn.Urls.MetadataURL+"/"+id, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := TLSClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("deleting service %s failed with status %d", id, resp.StatusCode)
+	}
+
+	KymaConn.Registrations = untrackRegistration(KymaConn.Registrations, id)
+	return nil
+}
+
+//ListRegistrations - lists the services of the metadata url, the details of each are read for the target url and spec type
+func (KymaConn *restConnector) listRegistrations(TLSClient *http.Client) ([]RegistrationDetails, error) {
+	log.Println("ListRegistrations via rest")
 
 	if KymaConn.Urls.MetadataURL == "" {
 		return nil, errors.New("no MetadataURL exists")
 	}
 
-	resp, err := TLSClient.Post(KymaConn.Urls.MetadataURL, "application/json", bytes.NewBuffer(EventSpec))
+	var services []serviceResp
+	if err := getJSON(TLSClient, KymaConn.Urls.MetadataURL, &services); err != nil {
+		return nil, err
+	}
+
+	registrations := []RegistrationDetails{}
+
+	for _, service := range services {
+		var details serviceDetails
+		if err := getJSON(TLSClient, KymaConn.Urls.MetadataURL+"/"+service.ID, &details); err != nil {
+			return nil, err
+		}
+
+		if details.API != nil {
+			specType := strings.ToUpper(details.API.APIType)
+			if specType == "" && len(details.API.Spec) > 0 {
+				specType = "OPEN_API"
+			}
+
+			registrations = append(registrations, RegistrationDetails{
+				ID:          service.ID,
+				Kind:        RegistrationKindAPI,
+				Name:        details.Name,
+				TargetURL:   details.API.TargetURL,
+				SpecType:    specType,
+				Labels:      details.Labels,
+				ContentHash: details.Labels[contentHashLabel],
+			})
+		}
+
+		if details.Events != nil {
+			var specType string
+			if len(details.Events.Spec) > 0 {
+				specType = "ASYNC_API"
+			}
+
+			registrations = append(registrations, RegistrationDetails{
+				ID:          service.ID,
+				Kind:        RegistrationKindEvents,
+				Name:        details.Name,
+				SpecType:    specType,
+				Labels:      details.Labels,
+				ContentHash: details.Labels[contentHashLabel],
+			})
+		}
+	}
+
+	return registrations, nil
+}
+
+//reads a json response, error statuses are returned as errors
+func getJSON(TLSClient *http.Client, url string, result interface{}) error {
+
+	resp, err := TLSClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("GET %s failed with status %d: %s", url, resp.StatusCode, string(respBody))
+	}
+
+	return json.Unmarshal(respBody, result)
+}
+
+//RevokeCertificate - revokes the current client certificate via the revokeCertUrl
+func (KymaConn *restConnector) revokeCertificate(TLSClient *http.Client) error {
+	log.Println("RevokeCertificate via rest...")
+
+	if KymaConn.Urls.RevokeCertURL == "" {
+		return errors.New("no RevokeCertURL exists")
+	}
+
+	resp, err := TLSClient.Post(KymaConn.Urls.RevokeCertURL, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("certificate revocation failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+//UnregisterServices - deletes every service created via the metadata url
+func (KymaConn *restConnector) unregisterServices(TLSClient *http.Client) error {
+	log.Println("UnregisterServices via rest...")
+
+	var lastErr error
+
+	//deleteService shrinks the list, so a copy is iterated
+	for _, registration := range append([]Registration{}, KymaConn.Registrations...) {
+		if err := KymaConn.deleteService(TLSClient, registration.ID); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+//keeps track of the services created so they can be updated and removed again
+func (KymaConn *restConnector) addServiceID(respBody []byte, kind string) string {
+	var service serviceResp
+
+	if err := json.Unmarshal(respBody, &service); err != nil || service.ID == "" {
+		log.Println("addServiceID: no service id found in response")
+		return ""
+	}
+
+	KymaConn.Registrations = trackRegistration(KymaConn.Registrations, service.ID, kind)
+	return service.ID
+}
+
+//RenewCertificate - sends a new csr to the renewCertUrl authenticated with the current client certificate
+func (KymaConn *restConnector) renewCertificate(TLSClient *http.Client, csr []byte) (*csrConnectResponse, error) {
+	log.Println("RenewCertificate via rest...")
+
+	if KymaConn.Urls.RenewCertURL == "" {
+		return nil, errors.New("no RenewCertURL exists")
+	}
+
+	csrJSON := []byte(fmt.Sprintf("{\"csr\":\"%s\"}", base64.StdEncoding.EncodeToString(csr)))
+
+	resp, err := TLSClient.Post(KymaConn.Urls.RenewCertURL, "application/json", bytes.NewBuffer(csrJSON))
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	return respBody, nil
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("certificate renewal failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	csrRespData := &csrConnectResponse{}
+	err = json.Unmarshal(respBody, csrRespData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return csrRespData, nil
 }
 
 func (KymaConn *restConnector) getCertificateSubject() string {
@@ -147,6 +488,18 @@ func (KymaConn *restConnector) getCertificateSubject() string {
 	return KymaConn.Certificate.Subject
 }
 
+func (KymaConn *restConnector) getKeyAlgorithm() string {
+	return KymaConn.Certificate.KeyAlgorithm
+}
+
+func (KymaConn *restConnector) getCertificateExtensions() string {
+	return KymaConn.Certificate.Extensions
+}
+
+func (KymaConn *restConnector) getRegistrations() []Registration {
+	return KymaConn.Registrations
+}
+
 func (KymaConn *restConnector) getEventURL() string {
 	log.Println("getEventURL via rest")
 
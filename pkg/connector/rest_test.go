@@ -0,0 +1,65 @@
+package connector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//a connector refusing every call with the status and body
+func newRefusingServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func checkRefused(t *testing.T, call string, err error, status int, body string) {
+	if err == nil {
+		t.Errorf("%s succeeded, want an error", call)
+		return
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("status %d", status)) || !strings.Contains(err.Error(), body) {
+		t.Errorf("%s error = %q, want the status %d and the body %s", call, err, status, body)
+	}
+}
+
+func TestRestConnectorErrorStatuses(t *testing.T) {
+
+	body := `{"code": 403, "error": "Invalid token."}`
+	server := newRefusingServer(http.StatusForbidden, body)
+	defer server.Close()
+
+	rest := &restConnector{CsrURL: server.URL + "/v1/applications/certificates?token=used"}
+
+	_, err := rest.callTokenURL(server.URL + "/v1/applications/signingRequests/info?token=used")
+	checkRefused(t, "callTokenURL", err, http.StatusForbidden, body)
+
+	_, err = rest.sendCSRToKyma([]byte("csr"))
+	checkRefused(t, "sendCSRToKyma", err, http.StatusForbidden, body)
+}
+
+func TestGraphQLConnectorErrorStatuses(t *testing.T) {
+
+	body := `{"errors": [{"message": "Invalid token"}]}`
+	server := newRefusingServer(http.StatusForbidden, body)
+	defer server.Close()
+
+	tokenData := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(`{"connectorURL": %q, "token": "used"}`, server.URL+"/graphql")))
+
+	compass := &graphQLConnector{}
+	_, err := compass.callTokenURL(tokenData)
+	checkRefused(t, "callTokenURL", err, http.StatusForbidden, body)
+
+	_, err = compass.sendCSRToKyma([]byte("csr"))
+	checkRefused(t, "sendCSRToKyma", err, http.StatusForbidden, body)
+
+	invalid := base64.StdEncoding.EncodeToString([]byte("not json"))
+	if _, err := (&graphQLConnector{}).callTokenURL(invalid); err == nil || !strings.Contains(err.Error(), "invalid token data") {
+		t.Errorf("callTokenURL of invalid token data = %v, want an error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package connector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jcawley/kyma-app-connector/pkg/store"
+)
+
+//version of the snapshot format, increase when the layout changes
+const snapshotVersion int = 1
+
+const snapshotFile string = "connection.json"
+
+//how long the startup probe may take before the restored connection is considered broken
+const probeTimeout = 30 * time.Second
+
+//connectionSnapshot - the persisted state of a connection, stored next to the certificates
+type connectionSnapshot struct {
+	Version               int             `json:"version"`
+	ConnectionType        string          `json:"connectionType"`
+	KeyAlgorithm          string          `json:"keyAlgorithm,omitempty"`
+	SubjectAltNames       []string        `json:"subjectAltNames,omitempty"`
+	EventMode             string          `json:"eventMode,omitempty"`
+	EventURL              string          `json:"eventURL,omitempty"`
+	CertificateObtainedAt time.Time       `json:"certificateObtainedAt"`
+	SavedAt               time.Time       `json:"savedAt"`
+	Connector             json.RawMessage `json:"connector"`
+}
+
+//writes the connector metadata so the connection can be restored after a restart
+func (config *apiConfig) saveSnapshot() error {
+
+	if config.kc == nil {
+		return nil
+	}
+
+	connectorData, err := json.Marshal(config.kc)
+	if err != nil {
+		return err
+	}
+
+	snapshot := connectionSnapshot{
+		Version:               snapshotVersion,
+		ConnectionType:        config.ConnectionType,
+		KeyAlgorithm:          config.KeyAlgorithm,
+		SubjectAltNames:       config.SubjectAltNames,
+		EventMode:             config.EventMode,
+		EventURL:              config.EventURL,
+		CertificateObtainedAt: config.certObtained,
+		SavedAt:               time.Now().UTC(),
+		Connector:             connectorData,
+	}
+
+	snapshotData, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return config.saveCredential(snapshotFile, snapshotData)
+}
+
+//logs instead of failing the calling step, the connection itself is still usable
+func (config *apiConfig) persist() {
+	if err := config.saveSnapshot(); err != nil {
+		log.Printf("could not save the connection state: %s", err)
+	}
+}
+
+//rehydrates the connector from the snapshot, rebuilds the TLS client and verifies it against the system
+func (config *apiConfig) restoreSnapshot() error {
+
+	snapshotData, err := config.loadCredential(snapshotFile)
+	if err == store.ErrNotFound {
+		log.Println("restoreSnapshot: no connection state found")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snapshot connectionSnapshot
+	if err := json.Unmarshal(snapshotData, &snapshot); err != nil {
+		return err
+	}
+
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported connection state version %d", snapshot.Version)
+	}
+
+	config.initConnectionType(snapshot.ConnectionType)
+	config.KeyAlgorithm = snapshot.KeyAlgorithm
+	config.SubjectAltNames = snapshot.SubjectAltNames
+	config.EventMode = snapshot.EventMode
+	config.EventURL = snapshot.EventURL
+	config.certObtained = snapshot.CertificateObtainedAt
+
+	if err := json.Unmarshal(snapshot.Connector, config.kc); err != nil {
+		return err
+	}
+
+	if err := config.setTLSClient(); err != nil {
+		return err
+	}
+
+	probeClient := *config.HTTPTLSClient
+	probeClient.Timeout = probeTimeout
+
+	//without a package config no package is created
+	if _, err := config.kc.getAppInfo(&probeClient, PackageConfig{}); err != nil {
+		//a revoked certificate or unregistered app cannot be renewed
+		config.stopMonitor()
+		config.ConnectionStatus = notConnected
+		return fmt.Errorf("restored connection could not be verified: %s", err)
+	}
+
+	config.persist()
+
+	log.Printf("Restored connection of type: %s", config.ConnectionType)
+	return nil
+}
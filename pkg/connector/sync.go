@@ -0,0 +1,352 @@
+package connector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+	"github.com/jcawley/kyma-app-connector/pkg/validate"
+)
+
+//label of a rest service holding the content hash of the declared spec
+const contentHashLabel string = "app-conn-content-hash"
+
+//application label (graphql) holding the content hashes by definition id
+const contentHashesLabel string = "app_conn_content_hashes"
+
+const syncCreated string = "created"
+const syncUpdated string = "updated"
+const syncUnchanged string = "unchanged"
+const syncDeleted string = "deleted"
+const syncFailed string = "failed"
+
+//SpecDeclaration - an api or event spec that should be registered under its name
+//spec is the rest service envelope or the open api / async api document, either as json or as a string,
+//file names a document of assets/spec-docs used instead
+type SpecDeclaration struct {
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	TargetURL   string            `json:"targetUrl,omitempty"`
+	File        string            `json:"file,omitempty"`
+	Spec        json.RawMessage   `json:"spec,omitempty"`
+}
+
+//SyncRequest - the declared spec set, the sample specs are declared if none are given
+//invalid specs fail unless forced
+type SyncRequest struct {
+	HostURL string            `json:"hostURL,omitempty"`
+	Prune   bool              `json:"prune"`
+	Force   bool              `json:"force,omitempty"`
+	Specs   []SpecDeclaration `json:"specs,omitempty"`
+}
+
+//SyncResult - what was done for a declared spec or a pruned registration
+type SyncResult struct {
+	Name     string             `json:"name"`
+	Kind     string             `json:"kind"`
+	ID       string             `json:"id,omitempty"`
+	Action   string             `json:"action"`
+	Error    string             `json:"error,omitempty"`
+	Problems []validate.Problem `json:"problems,omitempty"`
+}
+
+//SyncReport - result of a sync, contains a result for every declared spec and pruned registration
+type SyncReport struct {
+	Connection string       `json:"connection"`
+	Success    bool         `json:"success"`
+	Results    []SyncResult `json:"results"`
+}
+
+//the fields of a rest service envelope used to complete a declaration
+type serviceEnvelope struct {
+	Name string `json:"name"`
+	API  *struct {
+		TargetURL string `json:"targetUrl"`
+	} `json:"api"`
+}
+
+//SyncRegistrations - converges the registrations of the application to the declared specs
+func SyncRegistrations(w http.ResponseWriter, r *http.Request) {
+	log.Println("SyncRegistrations")
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.ReturnError("Could not read the body text", w)
+		return
+	}
+
+	var syncReq SyncRequest
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &syncReq); err != nil {
+			utils.ReturnError("Could not parse the request: "+err.Error(), w)
+			return
+		}
+	}
+
+	report, err := Sync(ConnectionName(r), syncReq)
+	if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else if report.Success {
+		utils.ReturnJSON(report, w)
+	} else {
+		utils.ReturnJSONWithStatus(report, http.StatusBadGateway, w)
+	}
+}
+
+//Sync - creates the declared specs missing for the named connection, updates the changed ones and
+//prunes the ones no longer declared if requested
+func Sync(name string, syncReq SyncRequest) (SyncReport, error) {
+	log.Printf("Sync %s", name)
+
+	config := getConnection(name)
+	if config == nil {
+		return SyncReport{}, errNoTLSConnection
+	}
+
+	//the registrations must not change between listing and matching them
+	config.mu.Lock()
+	results, err := config.sync(syncReq)
+	config.mu.Unlock()
+
+	if err != nil {
+		return SyncReport{}, err
+	}
+
+	report := SyncReport{
+		Connection: name,
+		Success:    true,
+		Results:    results,
+	}
+	for _, result := range results {
+		if result.Action == syncFailed {
+			report.Success = false
+		}
+	}
+
+	return report, nil
+}
+
+//registrations are matched by kind and name, the content hash decides if a match is updated
+//only registrations made by this app or by a sync are pruned
+func (config *apiConfig) sync(syncReq SyncRequest) ([]SyncResult, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	specs := syncReq.Specs
+	if len(specs) == 0 {
+		var err error
+		if specs, err = config.sampleSpecs(); err != nil {
+			return nil, err
+		}
+	}
+
+	declared := map[string]bool{}
+	for i := range specs {
+		if err := config.resolveDeclaration(&specs[i]); err != nil {
+			return nil, err
+		}
+		if err := config.completeDeclaration(&specs[i], syncReq.HostURL); err != nil {
+			return nil, err
+		}
+
+		key := specs[i].Kind + "/" + specs[i].Name
+		if declared[key] {
+			return nil, fmt.Errorf("%s %q is declared more than once", specs[i].Kind, specs[i].Name)
+		}
+		declared[key] = true
+	}
+
+	existing, err := config.listRegistrations()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := map[string]bool{}
+	results := []SyncResult{}
+
+	for _, spec := range specs {
+		result := SyncResult{Name: spec.Name, Kind: spec.Kind, Action: syncCreated}
+		contentHash := spec.contentHash()
+
+		current := findRegistration(existing, matched, spec.Kind, spec.Name)
+		if current != nil {
+			matched[current.ID] = true
+			result.ID = current.ID
+			result.Action = syncUpdated
+
+			if current.ContentHash == contentHash {
+				result.Action = syncUnchanged
+				results = append(results, result)
+				continue
+			}
+		}
+
+		if err := config.checkDeclaration(spec, syncReq.Force); err != nil {
+			result.Action = syncFailed
+			result.Error = err.Error()
+			if invalid, ok := err.(*InvalidSpecError); ok {
+				result.Problems = invalid.Problems
+			}
+			results = append(results, result)
+			continue
+		}
+
+		id, err := config.kc.saveSpec(config.HTTPTLSClient, result.ID, spec, contentHash)
+		if err != nil {
+			log.Printf("Sync %s: %s %s failed: %s", config.Name, result.Action, spec.Name, err)
+			result.Action = syncFailed
+			result.Error = err.Error()
+		} else {
+			result.ID = id
+		}
+		results = append(results, result)
+	}
+
+	if syncReq.Prune {
+		for _, registration := range existing {
+			if matched[registration.ID] || (registration.ContentHash == "" && !registration.Tracked) {
+				continue
+			}
+			//a rest service with an api and events is listed twice but deleted once
+			matched[registration.ID] = true
+
+			result := SyncResult{Name: registration.Name, Kind: registration.Kind, ID: registration.ID, Action: syncDeleted}
+
+			if registration.Kind == RegistrationKindEvents {
+				err = config.kc.deleteEventSpec(config.HTTPTLSClient, registration.ID)
+			} else {
+				err = config.kc.deleteAPISpec(config.HTTPTLSClient, registration.ID)
+			}
+			if err != nil {
+				log.Printf("Sync %s: pruning %s failed: %s", config.Name, registration.Name, err)
+				result.Action = syncFailed
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+		}
+	}
+
+	config.persist()
+	return results, nil
+}
+
+//the first registration with the kind and name that has not been matched yet
+func findRegistration(registrations []RegistrationDetails, matched map[string]bool, kind string, name string) *RegistrationDetails {
+	for i := range registrations {
+		registration := &registrations[i]
+		if registration.Kind == kind && registration.Name == name && !matched[registration.ID] {
+			return registration
+		}
+	}
+	return nil
+}
+
+//the sample api and event specs
+func (config *apiConfig) sampleSpecs() ([]SpecDeclaration, error) {
+
+	APISpec, err := config.sampleAPISpec()
+	if err != nil {
+		return nil, err
+	}
+
+	EventSpec, err := config.sampleEventSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	apiDeclaration := SpecDeclaration{Kind: RegistrationKindAPI, Spec: textSpec(APISpec)}
+	eventDeclaration := SpecDeclaration{Kind: RegistrationKindEvents, Spec: textSpec(EventSpec)}
+
+	//the rest envelopes carry their names
+	if config.ConnectionType == appTypeGraphQL {
+		apiDeclaration.Name = sampleAPIName
+		eventDeclaration.Name = sampleEventName
+	}
+
+	return []SpecDeclaration{apiDeclaration, eventDeclaration}, nil
+}
+
+//checks the declaration and fills in the name and target url
+func (config *apiConfig) completeDeclaration(spec *SpecDeclaration, hostURL string) error {
+
+	if spec.Kind != RegistrationKindAPI && spec.Kind != RegistrationKindEvents {
+		return fmt.Errorf("kind of %q must be %s or %s", spec.Name, RegistrationKindAPI, RegistrationKindEvents)
+	}
+
+	document := spec.document()
+	if len(document) == 0 {
+		return fmt.Errorf("no spec declared for %q", spec.Name)
+	}
+
+	var envelope serviceEnvelope
+	if config.ConnectionType == appTypeRest {
+		if err := json.Unmarshal(document, &envelope); err != nil {
+			return fmt.Errorf("spec of %q is not a service: %s", spec.Name, err)
+		}
+	}
+
+	if spec.Name == "" {
+		spec.Name = envelope.Name
+	}
+	if spec.Name == "" {
+		return fmt.Errorf("no name declared for a spec of kind %s", spec.Kind)
+	}
+
+	if spec.Kind != RegistrationKindAPI || spec.TargetURL != "" {
+		return nil
+	}
+
+	if hostURL != "" {
+		spec.TargetURL = hostURL
+	} else if envelope.API != nil && envelope.API.TargetURL != "" {
+		spec.TargetURL = envelope.API.TargetURL
+	} else {
+		spec.TargetURL = string(defaultHostURL(nil))
+	}
+	return nil
+}
+
+//the spec document, a json string contains the document as text
+func (spec SpecDeclaration) document() []byte {
+	var text string
+	if err := json.Unmarshal(spec.Spec, &text); err == nil {
+		return []byte(text)
+	}
+	return spec.Spec
+}
+
+//identifies the declared content, the name and kind are part of it as they are sent along
+func (spec SpecDeclaration) contentHash() string {
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(spec.Kind), []byte(spec.Name), []byte(spec.TargetURL), spec.document()} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+
+	//added only if set to keep the hashes of specs declared without them
+	if spec.Description != "" || len(spec.Labels) > 0 {
+		labels, _ := json.Marshal(spec.Labels)
+		hash.Write([]byte(spec.Description))
+		hash.Write([]byte{0})
+		hash.Write(labels)
+	}
+
+	//label values are limited to 63 characters
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
+
+//declares a document as text
+func textSpec(document []byte) json.RawMessage {
+	text, _ := json.Marshal(string(document))
+	return text
+}
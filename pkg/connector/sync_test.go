@@ -0,0 +1,78 @@
+package connector_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+//the actions of the results by kind and name
+func syncActions(t *testing.T, name string, syncReq connector.SyncRequest) (map[string]string, map[string]string) {
+	report, err := connector.Sync(name, syncReq)
+	if err != nil {
+		t.Fatalf("Sync: %s", err)
+	}
+	if !report.Success {
+		t.Errorf("Sync failed: %+v", report.Results)
+	}
+
+	actions := map[string]string{}
+	ids := map[string]string{}
+	for _, result := range report.Results {
+		actions[result.Kind+"/"+result.Name] = result.Action
+		ids[result.Kind+"/"+result.Name] = result.ID
+	}
+	return actions, ids
+}
+
+func TestSyncIsIdempotent(t *testing.T) {
+
+	for _, newSystem := range []func(t *testing.T) testSystem{newKymaSystem, newCompassSystem} {
+		system := newSystem(t)
+		t.Run(system.name, func(t *testing.T) {
+			defer system.close()
+
+			credentials := newTestStore()
+			name := system.name + "-sync"
+			connectTestSystem(t, name, system)
+
+			api := connector.SpecDeclaration{Name: "orders", Kind: connector.RegistrationKindAPI, File: "api-graphql.yaml"}
+			events := connector.SpecDeclaration{Name: "order-events", Kind: connector.RegistrationKindEvents, File: "event-graphql.yaml"}
+			apiKey := api.Kind + "/" + api.Name
+			eventsKey := events.Kind + "/" + events.Name
+
+			declared := func(specs ...connector.SpecDeclaration) connector.SyncRequest {
+				return connector.SyncRequest{HostURL: "http://localhost:8000", Specs: specs}
+			}
+
+			actions, created := syncActions(t, name, declared(api, events))
+			if expected := map[string]string{apiKey: "created", eventsKey: "created"}; !reflect.DeepEqual(actions, expected) {
+				t.Fatalf("first sync = %v, want %v", actions, expected)
+			}
+
+			actions, ids := syncActions(t, name, declared(api, events))
+			if expected := map[string]string{apiKey: "unchanged", eventsKey: "unchanged"}; !reflect.DeepEqual(actions, expected) {
+				t.Errorf("second sync = %v, want %v", actions, expected)
+			}
+			if !reflect.DeepEqual(ids, created) {
+				t.Errorf("second sync ids = %v, want the created ones %v", ids, created)
+			}
+
+			//the content hashes are kept with the registrations, a restored connection finds them
+			connector.SetCredentialStore(credentials)
+
+			changed := api
+			changed.Description = "Orders of the sample shop"
+			actions, _ = syncActions(t, name, declared(changed, events))
+			if expected := map[string]string{apiKey: "updated", eventsKey: "unchanged"}; !reflect.DeepEqual(actions, expected) {
+				t.Errorf("sync of a changed description = %v, want %v", actions, expected)
+			}
+
+			actions, _ = syncActions(t, name, declared(changed, events))
+			if expected := map[string]string{apiKey: "unchanged", eventsKey: "unchanged"}; !reflect.DeepEqual(actions, expected) {
+				t.Errorf("sync after the update = %v, want %v", actions, expected)
+			}
+		})
+	}
+}
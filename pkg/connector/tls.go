@@ -0,0 +1,147 @@
+package connector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+//CABundleEnv - file or directory with additional PEM encoded CA certificates trusted for all connections
+const CABundleEnv string = "APP_CONN_CA_BUNDLE"
+
+//InsecureSkipVerifyEnv - set to true to disable the verification of the server certificates
+const InsecureSkipVerifyEnv string = "APP_CONN_INSECURE_SKIP_VERIFY"
+
+//trustSettings - how the certificates presented by kyma or compass are verified
+type trustSettings struct {
+	insecureSkipVerify bool
+	caBundlePath       string
+}
+
+var trust trustSettings
+
+//client for the calls made before a client certificate exists (token url, csr)
+var publicClient = http.DefaultClient
+
+func initTrust() {
+
+	trust = trustSettings{
+		caBundlePath: os.Getenv(CABundleEnv),
+	}
+
+	if value := os.Getenv(InsecureSkipVerifyEnv); value != "" {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("invalid value %q for %s, server certificates will be verified", value, InsecureSkipVerifyEnv)
+		}
+		trust.insecureSkipVerify = insecure
+	}
+
+	if trust.insecureSkipVerify {
+		log.Println("**************************************************************************")
+		log.Printf("WARNING: %s is set, server certificates are NOT verified.", InsecureSkipVerifyEnv)
+		log.Println("WARNING: connections can be intercepted, never use this with shared clusters.")
+		log.Println("**************************************************************************")
+	}
+
+	pool, err := trust.systemAndBundlePool()
+	if err != nil {
+		log.Printf("could not load the CA bundle: %s", err)
+	}
+
+	publicClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: trust.insecureSkipVerify,
+				RootCAs:            pool,
+			},
+		},
+	}
+}
+
+//the system roots plus the configured CA bundle
+func (trust trustSettings) systemAndBundlePool() (*x509.CertPool, error) {
+
+	pool, _ := x509.SystemCertPool()
+	if pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if trust.caBundlePath == "" {
+		return pool, nil
+	}
+
+	bundleFiles := []string{trust.caBundlePath}
+
+	info, err := os.Stat(trust.caBundlePath)
+	if err != nil {
+		return pool, err
+	}
+
+	if info.IsDir() {
+		entries, err := ioutil.ReadDir(trust.caBundlePath)
+		if err != nil {
+			return pool, err
+		}
+
+		bundleFiles = nil
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				bundleFiles = append(bundleFiles, filepath.Join(trust.caBundlePath, entry.Name()))
+			}
+		}
+	}
+
+	for _, bundleFile := range bundleFiles {
+		bundle, err := ioutil.ReadFile(bundleFile)
+		if err != nil {
+			return pool, err
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			log.Printf("no certificates found in %s", bundleFile)
+		}
+	}
+
+	return pool, nil
+}
+
+//the CAs trusted for the connection: system roots, the configured bundle and the CA returned when the csr was signed
+func (config *apiConfig) rootCAs() (*x509.CertPool, error) {
+
+	pool, err := trust.systemAndBundlePool()
+	if err != nil {
+		return nil, fmt.Errorf("could not load the CA bundle: %s", err)
+	}
+
+	caCrt, err := config.loadCredential("ca.crt")
+	if err == nil {
+		pool.AppendCertsFromPEM(caCrt)
+		return pool, nil
+	}
+
+	//connections created before the CA was stored, use the CA certificates contained in the chain
+	log.Println("rootCAs: no ca.crt exists, using the CAs of the certificate chain")
+
+	crtChain, err := config.loadCredential("crtChain.crt")
+	if err != nil {
+		return pool, nil
+	}
+
+	for block, rest := pem.Decode(crtChain); block != nil; block, rest = pem.Decode(rest) {
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err == nil && certificate.IsCA {
+			pool.AddCert(certificate)
+		}
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,365 @@
+package connector
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+	"github.com/jcawley/kyma-app-connector/pkg/validate"
+)
+
+//provider of the services wrapped around uploaded documents
+const uploadProvider string = "kyma-app-conn-demo"
+
+//largest multipart upload kept in memory
+const maxUploadMemory int64 = 10 << 20
+
+//SpecDoc - a document of assets/spec-docs that can be registered
+type SpecDoc struct {
+	File string `json:"file"`
+	Kind string `json:"kind,omitempty"`
+	//service for a rest service envelope, else openapi or asyncapi
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+//ListSpecDocs - lists the documents of assets/spec-docs
+func ListSpecDocs(w http.ResponseWriter, r *http.Request) {
+	log.Println("ListSpecDocs")
+
+	docs, err := listSpecDocs()
+	if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnJSON(docs, w)
+	}
+}
+
+//GetSpecDocs - lists the documents of assets/spec-docs
+func GetSpecDocs() ([]SpecDoc, error) {
+	return listSpecDocs()
+}
+
+func specDocsDir() string {
+	return filepath.Join(assetsDir, "spec-docs")
+}
+
+func listSpecDocs() ([]SpecDoc, error) {
+
+	files, err := ioutil.ReadDir(specDocsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []SpecDoc{}
+	for _, file := range files {
+		switch strings.ToLower(filepath.Ext(file.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+
+		doc := SpecDoc{File: file.Name()}
+		if document, err := readSpecDoc(file.Name()); err == nil {
+			doc.Kind, doc.Type, doc.Title = describeSpec(document)
+		}
+		docs = append(docs, doc)
+	}
+
+	sort.Slice(docs, func(i, j int) bool { return docs[i].File < docs[j].File })
+	return docs, nil
+}
+
+//reads a document of assets/spec-docs, other directories cannot be reached
+func readSpecDoc(file string) ([]byte, error) {
+	if file != filepath.Base(file) || file == "." || file == ".." {
+		return nil, fmt.Errorf("%q is not a file of spec-docs", file)
+	}
+	return ioutil.ReadFile(filepath.Join(specDocsDir(), file))
+}
+
+//the kind, type and title of a document, empty if it is neither a service nor an open api or async api document
+func describeSpec(document []byte) (string, string, string) {
+
+	value, err := validate.Parse(document)
+	if err != nil {
+		return "", "", ""
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return "", "", ""
+	}
+
+	title := ""
+	if info, ok := object["info"].(map[string]interface{}); ok {
+		title, _ = info["title"].(string)
+	}
+
+	switch {
+	case object["openapi"] != nil || object["swagger"] != nil:
+		return RegistrationKindAPI, "openapi", title
+	case object["asyncapi"] != nil:
+		return RegistrationKindEvents, "asyncapi", title
+	case object["api"] != nil:
+		name, _ := object["name"].(string)
+		return RegistrationKindAPI, "service", name
+	case object["events"] != nil:
+		name, _ := object["name"].(string)
+		return RegistrationKindEvents, "service", name
+	}
+	return "", "", ""
+}
+
+//reads a custom spec from a json or multipart body, nil if the body is the hostURL of the sample spec
+func readUpload(r *http.Request, kind string) (*SpecDeclaration, []byte, error) {
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "multipart/form-data" {
+		spec, err := readMultipartUpload(r, kind)
+		return spec, nil, err
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, errors.New("Could not read the body text")
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		return nil, body, nil
+	}
+
+	spec := SpecDeclaration{}
+	if err := json.Unmarshal(body, &spec); err != nil {
+		return nil, nil, errors.New("Could not parse the spec: " + err.Error())
+	}
+	spec.Kind = kind
+	return &spec, nil, nil
+}
+
+//the spec is a file or text field, labels a json object or repeated key=value fields
+func readMultipartUpload(r *http.Request, kind string) (*SpecDeclaration, error) {
+
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		return nil, errors.New("Could not parse the form: " + err.Error())
+	}
+
+	spec := SpecDeclaration{
+		Kind:        kind,
+		Name:        r.FormValue("name"),
+		Description: r.FormValue("description"),
+		TargetURL:   r.FormValue("targetUrl"),
+		File:        r.FormValue("file"),
+	}
+
+	if labels := r.FormValue("labels"); strings.TrimSpace(labels) != "" {
+		if err := json.Unmarshal([]byte(labels), &spec.Labels); err != nil {
+			return nil, errors.New("labels must be a json object of strings: " + err.Error())
+		}
+	}
+	for _, label := range r.MultipartForm.Value["label"] {
+		if err := addLabel(&spec, label); err != nil {
+			return nil, err
+		}
+	}
+
+	if file, _, err := r.FormFile("spec"); err == nil {
+		defer file.Close()
+		document, err := ioutil.ReadAll(file)
+		if err != nil {
+			return nil, errors.New("Could not read the spec: " + err.Error())
+		}
+		spec.Spec = textSpec(document)
+	} else if document := r.FormValue("spec"); document != "" {
+		spec.Spec = textSpec([]byte(document))
+	}
+
+	return &spec, nil
+}
+
+//adds a key=value label
+func addLabel(spec *SpecDeclaration, label string) error {
+	separator := strings.Index(label, "=")
+	if separator < 1 {
+		return fmt.Errorf("label %q is not of the form key=value", label)
+	}
+
+	if spec.Labels == nil {
+		spec.Labels = map[string]string{}
+	}
+	spec.Labels[label[:separator]] = label[separator+1:]
+	return nil
+}
+
+//ParseLabels - parses key=value labels
+func ParseLabels(labels []string) (map[string]string, error) {
+	spec := SpecDeclaration{}
+	for _, label := range labels {
+		if err := addLabel(&spec, label); err != nil {
+			return nil, err
+		}
+	}
+	return spec.Labels, nil
+}
+
+//registers a custom spec, the sample spec is used if it has neither a document nor a file
+func (config *apiConfig) uploadSpec(spec SpecDeclaration, force bool) ([]byte, error) {
+
+	if config.HTTPTLSClient == nil {
+		return nil, errNoTLSConnection
+	}
+
+	if len(spec.Spec) == 0 && spec.File == "" {
+		samples, err := config.sampleSpecs()
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range samples {
+			if sample.Kind == spec.Kind {
+				spec.Spec = sample.Spec
+				if spec.Name == "" {
+					spec.Name = sample.Name
+				}
+			}
+		}
+	}
+
+	if err := config.resolveDeclaration(&spec); err != nil {
+		return nil, err
+	}
+	if err := config.completeDeclaration(&spec, ""); err != nil {
+		return nil, err
+	}
+	if err := config.checkDeclaration(spec, force); err != nil {
+		return nil, err
+	}
+
+	id, err := config.kc.saveSpec(config.HTTPTLSClient, "", spec, spec.contentHash())
+	if err != nil {
+		return nil, err
+	}
+
+	config.persist()
+	return []byte(fmt.Sprintf("{ID: %s}", id)), nil
+}
+
+//reads the file of the declaration, for rest open api and async api documents are wrapped into a service,
+//for graphql the document is taken out of a service, the name defaults to the title of the document
+func (config *apiConfig) resolveDeclaration(spec *SpecDeclaration) error {
+
+	if spec.File != "" && len(spec.Spec) == 0 {
+		document, err := readSpecDoc(spec.File)
+		if err != nil {
+			return err
+		}
+		spec.Spec = textSpec(document)
+	}
+
+	document := spec.document()
+	_, specType, title := describeSpec(document)
+	if specType == "service" {
+		if config.ConnectionType == appTypeRest {
+			return nil
+		}
+		return unwrapService(spec, document)
+	}
+
+	if spec.Name == "" {
+		spec.Name = title
+	}
+
+	if config.ConnectionType != appTypeRest || specType == "" {
+		return nil
+	}
+
+	service, err := wrapService(*spec, document)
+	if err != nil {
+		return err
+	}
+	spec.Spec = service
+	return nil
+}
+
+//the rest service carrying the document, the service envelope is json so yaml documents are converted
+func wrapService(spec SpecDeclaration, document []byte) (json.RawMessage, error) {
+
+	value, err := validate.Parse(document)
+	if err != nil {
+		return nil, err
+	}
+
+	description := spec.Description
+	if description == "" {
+		description = spec.Name
+	}
+
+	service := map[string]interface{}{
+		"provider":    uploadProvider,
+		"name":        spec.Name,
+		"description": description,
+	}
+	if spec.Kind == RegistrationKindEvents {
+		service["events"] = map[string]interface{}{"spec": value}
+	} else {
+		service["api"] = map[string]interface{}{"targetUrl": spec.TargetURL, "spec": value}
+	}
+
+	return json.Marshal(service)
+}
+
+//replaces the service by the document of its kind, the name, description and target url of the service are defaults
+//compass definitions have no labels, the ones of the service are dropped
+func unwrapService(spec *SpecDeclaration, document []byte) error {
+
+	var service struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		API         *struct {
+			TargetURL string          `json:"targetUrl"`
+			Spec      json.RawMessage `json:"spec"`
+		} `json:"api"`
+		Events *struct {
+			Spec json.RawMessage `json:"spec"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(document, &service); err != nil {
+		return fmt.Errorf("spec of %q is not a service: %s", spec.Name, err)
+	}
+
+	switch {
+	case spec.Kind == RegistrationKindAPI && service.API != nil:
+		spec.Spec = service.API.Spec
+		if spec.TargetURL == "" {
+			spec.TargetURL = service.API.TargetURL
+		}
+	case spec.Kind == RegistrationKindEvents && service.Events != nil:
+		spec.Spec = service.Events.Spec
+	default:
+		return fmt.Errorf("the service %q has no %s spec", service.Name, spec.Kind)
+	}
+
+	if spec.Name == "" {
+		spec.Name = service.Name
+	}
+	if spec.Description == "" {
+		spec.Description = service.Description
+	}
+	return nil
+}
+
+//escapes the characters sjson treats as path syntax
+func escapePath(key string) string {
+	for _, special := range []string{`\`, ".", "*", "?"} {
+		key = strings.Replace(key, special, `\`+special, -1)
+	}
+	return key
+}
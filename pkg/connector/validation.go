@@ -0,0 +1,104 @@
+package connector
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+	"github.com/jcawley/kyma-app-connector/pkg/validate"
+	"github.com/tidwall/sjson"
+)
+
+//InvalidSpecError - a spec was refused because validation found problems
+type InvalidSpecError struct {
+	Kind     string
+	Problems []validate.Problem
+}
+
+func (err *InvalidSpecError) Error() string {
+	problems := make([]string, len(err.Problems))
+	for i, problem := range err.Problems {
+		problems[i] = problem.String()
+	}
+	return fmt.Sprintf("the %s spec is invalid: %s", err.Kind, strings.Join(problems, "; "))
+}
+
+//invalidSpecResponse - body returned for a refused spec
+type invalidSpecResponse struct {
+	Error    string             `json:"error"`
+	Problems []validate.Problem `json:"problems"`
+}
+
+//checks an api or event spec as it would be sent for the connection type,
+//a rest service envelope or an open api / async api document
+func validateSpec(connectionType string, kind string, document []byte) []validate.Problem {
+	if connectionType == appTypeRest {
+		return validate.Service(document)
+	}
+	if kind == RegistrationKindEvents {
+		return validate.AsyncAPI(document)
+	}
+	return validate.OpenAPI(document)
+}
+
+//refuses an invalid spec unless forced, forced problems are only logged
+func (config *apiConfig) checkSpec(kind string, document []byte, force bool) error {
+
+	problems := validateSpec(config.ConnectionType, kind, document)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	err := &InvalidSpecError{Kind: kind, Problems: problems}
+	if force {
+		log.Printf("Sending the %s spec anyway: %s", kind, err)
+		return nil
+	}
+	return err
+}
+
+//checks an api spec with the target url it will be registered with
+func (config *apiConfig) checkAPISpec(APISpec []byte, hostURL []byte, force bool) error {
+
+	if config.ConnectionType == appTypeRest {
+		//the envelope may leave the target url to the hostURL
+		if service, err := sjson.SetBytes(APISpec, "api.targetUrl", string(hostURL)); err == nil {
+			APISpec = service
+		}
+	}
+	return config.checkSpec(RegistrationKindAPI, APISpec, force)
+}
+
+//checks a declared spec as it will be saved, the name and target url are part of a rest service
+func (config *apiConfig) checkDeclaration(spec SpecDeclaration, force bool) error {
+
+	document := spec.document()
+	if config.ConnectionType == appTypeRest {
+		if service, err := sjson.SetBytes(document, "name", spec.Name); err == nil {
+			document = service
+		}
+	}
+
+	if spec.Kind == RegistrationKindAPI {
+		return config.checkAPISpec(document, []byte(spec.TargetURL), force)
+	}
+	return config.checkSpec(spec.Kind, document, force)
+}
+
+//forced is set by ?force=true
+func forced(r *http.Request) bool {
+	return r.URL.Query().Get("force") == "true"
+}
+
+//writes the response of a step or its error, the problems of a refused spec are returned with a bad request
+func returnResult(resp []byte, err error, w http.ResponseWriter) {
+	if invalid, ok := err.(*InvalidSpecError); ok {
+		utils.ReturnJSONWithStatus(invalidSpecResponse{Error: invalid.Error(), Problems: invalid.Problems}, http.StatusBadRequest, w)
+	} else if err != nil {
+		utils.ReturnError(err.Error(), w)
+	} else {
+		utils.ReturnSuccess(string(resp), w)
+	}
+}
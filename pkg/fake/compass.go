@@ -0,0 +1,887 @@
+package fake
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
+)
+
+//Compass - local stand-in for the compass connector and director graphql apis
+//the connector is served on plain http and authenticated with the connector-token header,
+//the certificate secured connector, the director and the events endpoint require a client certificate
+type Compass struct {
+	ApplicationID       string
+	ApplicationName     string
+	CertificateValidity time.Duration
+	//key algorithm advertised for the csr, e.g. rsa2048 or ecdsa-p256
+	KeyAlgorithm string
+	CA           *CA
+
+	//base url of the server running ConnectorHandler
+	ConnectorURL string
+	//base url of the server running GatewayHandler
+	GatewayURL string
+
+	mu       sync.Mutex
+	tokens   map[string]bool
+	packages map[string]*compassPackage
+	order    []string
+	labels   map[string]interface{}
+	events   eventLog
+	auth     *clientAuth
+
+	connectorSchema graphql.Schema
+	directorSchema  graphql.Schema
+}
+
+//CompassTestServer - a Compass running on two httptest servers
+type CompassTestServer struct {
+	*Compass
+	Connector *httptest.Server
+	Gateway   *httptest.Server
+}
+
+type compassPackage struct {
+	ID                  string                    `json:"id"`
+	Name                string                    `json:"name"`
+	Description         string                    `json:"description"`
+	DefaultInstanceAuth interface{}               `json:"defaultInstanceAuth"`
+	APIDefinitions      []*compassAPIDefinition   `json:"-"`
+	EventDefinitions    []*compassEventDefinition `json:"-"`
+}
+
+type compassAPIDefinition struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	TargetURL   string       `json:"targetURL"`
+	Spec        *compassSpec `json:"spec"`
+}
+
+type compassEventDefinition struct {
+	ID          string       `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Spec        *compassSpec `json:"spec"`
+}
+
+type compassSpec struct {
+	Data   string `json:"data"`
+	Type   string `json:"type"`
+	Format string `json:"format"`
+}
+
+type contextKey int
+
+const requestKey contextKey = 0
+
+//NewCompass - creates the fake for an application with the given name
+func NewCompass(applicationName string) (*Compass, error) {
+
+	ca, err := NewCA("Compass Fake CA")
+	if err != nil {
+		return nil, err
+	}
+
+	applicationID := newUUID()
+
+	c := &Compass{
+		ApplicationID:       applicationID,
+		ApplicationName:     applicationName,
+		CertificateValidity: DefaultCertificateValidity,
+		KeyAlgorithm:        DefaultKeyAlgorithm,
+		CA:                  ca,
+		tokens:              map[string]bool{},
+		packages:            map[string]*compassPackage{},
+		labels:              map[string]interface{}{},
+		auth:                newClientAuth(applicationID),
+	}
+
+	if err := c.buildSchemas(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+//NewCompassTestServer - starts the connector and the gateway on local httptest servers
+func NewCompassTestServer(applicationName string) (*CompassTestServer, error) {
+
+	compass, err := NewCompass(applicationName)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, gateway, err := startTestServers(compass.CA, compass.ConnectorHandler(), compass.GatewayHandler())
+	if err != nil {
+		return nil, err
+	}
+
+	compass.ConnectorURL = connector.URL
+	compass.GatewayURL = gateway.URL
+
+	return &CompassTestServer{Compass: compass, Connector: connector, Gateway: gateway}, nil
+}
+
+//ListenAndServe - runs the connector on connectorAddr and the gateway on gatewayAddr, host is used in the advertised urls
+//a first token is logged once both listeners are bound
+func (c *Compass) ListenAndServe(host string, connectorAddr string, gatewayAddr string) error {
+	return listenAndServe(c.CA, host, connectorAddr, gatewayAddr, c.ConnectorHandler(), c.GatewayHandler(), func(connectorURL string, gatewayURL string) {
+		c.ConnectorURL = connectorURL
+		c.GatewayURL = gatewayURL
+
+		log.Printf("fake compass: application %s (%s), token data: %s", c.ApplicationName, c.ApplicationID, c.NewToken())
+		log.Printf("fake compass: new tokens via POST %s/tokens", c.ConnectorURL)
+	})
+}
+
+//Close - shuts down both servers
+func (s *CompassTestServer) Close() {
+	s.Connector.Close()
+	s.Gateway.Close()
+}
+
+//NewToken - returns base64 encoded token data (connectorURL and token) accepted by CallTokenURL
+func (c *Compass) NewToken() string {
+	tokenData, _ := json.Marshal(map[string]string{
+		"connectorURL": c.connectorGraphQLURL(),
+		"token":        c.newConnectorToken(),
+	})
+	return base64.StdEncoding.EncodeToString(tokenData)
+}
+
+//Events - the events received so far
+func (c *Compass) Events() []json.RawMessage {
+	return c.events.list()
+}
+
+//ConnectorHandler - the token authenticated connector graphql api
+func (c *Compass) ConnectorHandler() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/tokens", c.createToken).Methods("POST")
+	router.Handle("/graphql", graphQLHandler(c.connectorSchema)).Methods("POST")
+	return router
+}
+
+//GatewayHandler - the certificate secured connector, the director and the events endpoint
+func (c *Compass) GatewayHandler() http.Handler {
+	router := mux.NewRouter()
+	router.Use(c.auth.requireClientCertificate)
+	router.Handle("/connector/graphql", graphQLHandler(c.connectorSchema)).Methods("POST")
+	router.Handle("/director/graphql", graphQLHandler(c.directorSchema)).Methods("POST")
+	router.HandleFunc("/"+c.ApplicationName+"/v1/events", c.events.publishLegacyEvent).Methods("POST")
+	router.HandleFunc("/"+c.ApplicationName+"/v2/events", c.events.publishCloudEvent).Methods("POST")
+	router.HandleFunc("/"+c.ApplicationName+"/events", c.events.publishCloudEvent).Methods("POST")
+	return router
+}
+
+func (c *Compass) connectorGraphQLURL() string {
+	return c.ConnectorURL + "/graphql"
+}
+
+func (c *Compass) subject() string {
+	return fmt.Sprintf("C=DE,L=Waldorf,O=SAP SE,OU=SAP CP Kyma,ST=Waldorf,CN=%s", c.ApplicationID)
+}
+
+func (c *Compass) newConnectorToken() string {
+	token := newToken()
+
+	c.mu.Lock()
+	c.tokens[token] = true
+	c.mu.Unlock()
+
+	return token
+}
+
+//consumes a connector token
+func (c *Compass) useConnectorToken(token string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if token == "" || !c.tokens[token] {
+		return false
+	}
+	delete(c.tokens, token)
+	return true
+}
+
+func (c *Compass) createToken(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusCreated, map[string]string{"tokenData": c.NewToken()})
+}
+
+//requests to the connector are either authenticated with a client certificate or a connector token
+func (c *Compass) authenticate(ctx context.Context) error {
+	r := ctx.Value(requestKey).(*http.Request)
+
+	if peerCertificate(r) != nil {
+		//the gateway middleware has already validated the certificate
+		return nil
+	}
+
+	if !c.useConnectorToken(r.Header.Get("connector-token")) {
+		return errors.New("invalid connector token")
+	}
+	return nil
+}
+
+func (c *Compass) buildSchemas() error {
+
+	certificationResult := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CertificationResult",
+		Fields: graphql.Fields{
+			"certificateChain":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"caCertificate":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"clientCertificate": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	configuration := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Configuration",
+		Fields: graphql.Fields{
+			"token": &graphql.Field{Type: graphql.NewObject(graphql.ObjectConfig{
+				Name:   "Token",
+				Fields: graphql.Fields{"token": &graphql.Field{Type: graphql.NewNonNull(graphql.String)}},
+			})},
+			"certificateSigningRequestInfo": &graphql.Field{Type: graphql.NewObject(graphql.ObjectConfig{
+				Name: "CertificateSigningRequestInfo",
+				Fields: graphql.Fields{
+					"subject":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+					"keyAlgorithm": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				},
+			})},
+			"managementPlaneInfo": &graphql.Field{Type: graphql.NewObject(graphql.ObjectConfig{
+				Name: "ManagementPlaneInfo",
+				Fields: graphql.Fields{
+					"directorURL":                    &graphql.Field{Type: graphql.String},
+					"certificateSecuredConnectorURL": &graphql.Field{Type: graphql.String},
+				},
+			})},
+		},
+	})
+
+	connectorSchema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"configuration": &graphql.Field{
+					Type:    graphql.NewNonNull(configuration),
+					Resolve: c.resolveConfiguration,
+				},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"signCertificateSigningRequest": &graphql.Field{
+					Type: graphql.NewNonNull(certificationResult),
+					Args: graphql.FieldConfigArgument{
+						"csr": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					},
+					Resolve: c.resolveSignCSR,
+				},
+				"revokeCertificate": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.Boolean),
+					Resolve: c.resolveRevokeCertificate,
+				},
+			},
+		}),
+	})
+	if err != nil {
+		return err
+	}
+	c.connectorSchema = connectorSchema
+
+	directorSchema, err := graphql.NewSchema(c.directorSchemaConfig())
+	if err != nil {
+		return err
+	}
+	c.directorSchema = directorSchema
+
+	return nil
+}
+
+func (c *Compass) directorSchemaConfig() graphql.SchemaConfig {
+
+	specFormat := graphql.NewEnum(graphql.EnumConfig{
+		Name: "SpecFormat",
+		Values: graphql.EnumValueConfigMap{
+			"YAML": &graphql.EnumValueConfig{Value: "YAML"},
+			"JSON": &graphql.EnumValueConfig{Value: "JSON"},
+			"XML":  &graphql.EnumValueConfig{Value: "XML"},
+		},
+	})
+	apiSpecType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "APISpecType",
+		Values: graphql.EnumValueConfigMap{
+			"OPEN_API": &graphql.EnumValueConfig{Value: "OPEN_API"},
+			"ODATA":    &graphql.EnumValueConfig{Value: "ODATA"},
+		},
+	})
+	eventSpecType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "EventSpecType",
+		Values: graphql.EnumValueConfigMap{
+			"ASYNC_API": &graphql.EnumValueConfig{Value: "ASYNC_API"},
+		},
+	})
+
+	clob := newJSONScalar("CLOB")
+	anyJSON := newJSONScalar("JSON")
+	anyValue := newJSONScalar("Any")
+	labels := newJSONScalar("Labels")
+
+	apiSpec := graphql.NewObject(graphql.ObjectConfig{
+		Name: "APISpec",
+		Fields: graphql.Fields{
+			"data":   &graphql.Field{Type: clob},
+			"type":   &graphql.Field{Type: graphql.NewNonNull(apiSpecType)},
+			"format": &graphql.Field{Type: graphql.NewNonNull(specFormat)},
+		},
+	})
+	eventSpec := graphql.NewObject(graphql.ObjectConfig{
+		Name: "EventSpec",
+		Fields: graphql.Fields{
+			"data":   &graphql.Field{Type: clob},
+			"type":   &graphql.Field{Type: graphql.NewNonNull(eventSpecType)},
+			"format": &graphql.Field{Type: graphql.NewNonNull(specFormat)},
+		},
+	})
+
+	apiDefinition := graphql.NewObject(graphql.ObjectConfig{
+		Name: "APIDefinition",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.Field{Type: graphql.String},
+			"targetURL":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"spec":        &graphql.Field{Type: apiSpec},
+		},
+	})
+	eventDefinition := graphql.NewObject(graphql.ObjectConfig{
+		Name: "EventDefinition",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.Field{Type: graphql.String},
+			"spec":        &graphql.Field{Type: eventSpec},
+		},
+	})
+
+	packageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Package",
+		Fields: graphql.Fields{
+			"id":                  &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":                &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"description":         &graphql.Field{Type: graphql.String},
+			"defaultInstanceAuth": &graphql.Field{Type: anyJSON},
+			"apiDefinitions": &graphql.Field{
+				Type: pageOf("APIDefinitionPage", apiDefinition),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					c.mu.Lock()
+					defer c.mu.Unlock()
+					return newPage(p.Source.(*compassPackage).APIDefinitions), nil
+				},
+			},
+			"eventDefinitions": &graphql.Field{
+				Type: pageOf("EventDefinitionPage", eventDefinition),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					c.mu.Lock()
+					defer c.mu.Unlock()
+					return newPage(p.Source.(*compassPackage).EventDefinitions), nil
+				},
+			},
+		},
+	})
+
+	application := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Application",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"name":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"labels": &graphql.Field{Type: labels},
+			"eventingConfiguration": &graphql.Field{Type: graphql.NewObject(graphql.ObjectConfig{
+				Name:   "ApplicationEventingConfiguration",
+				Fields: graphql.Fields{"defaultURL": &graphql.Field{Type: graphql.NewNonNull(graphql.String)}},
+			})},
+			"packages": &graphql.Field{
+				Type: pageOf("PackagePage", packageType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return newPage(c.listPackages()), nil
+				},
+			},
+		},
+	})
+
+	label := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Label",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"value": &graphql.Field{Type: graphql.NewNonNull(anyValue)},
+		},
+	})
+
+	viewer := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Viewer",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+			"type": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	authInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "AuthInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"credential":                      &graphql.InputObjectFieldConfig{Type: anyJSON},
+			"additionalHeaders":               &graphql.InputObjectFieldConfig{Type: anyJSON},
+			"additionalQueryParams":           &graphql.InputObjectFieldConfig{Type: anyJSON},
+			"requestAuth":                     &graphql.InputObjectFieldConfig{Type: anyJSON},
+			"additionalHeadersSerialized":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"additionalQueryParamsSerialized": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	packageCreateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "PackageCreateInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":                           &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description":                    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"defaultInstanceAuth":            &graphql.InputObjectFieldConfig{Type: authInput},
+			"instanceAuthRequestInputSchema": &graphql.InputObjectFieldConfig{Type: anyJSON},
+		},
+	})
+	apiSpecInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "APISpecInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"data":   &graphql.InputObjectFieldConfig{Type: clob},
+			"type":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(apiSpecType)},
+			"format": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(specFormat)},
+		},
+	})
+	eventSpecInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "EventSpecInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"data":   &graphql.InputObjectFieldConfig{Type: clob},
+			"type":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(eventSpecType)},
+			"format": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(specFormat)},
+		},
+	})
+	apiDefinitionInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "APIDefinitionInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"targetURL":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"group":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"spec":        &graphql.InputObjectFieldConfig{Type: apiSpecInput},
+		},
+	})
+	eventDefinitionInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "EventDefinitionInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"group":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"spec":        &graphql.InputObjectFieldConfig{Type: eventSpecInput},
+		},
+	})
+
+	return graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"viewer": &graphql.Field{
+					Type: graphql.NewNonNull(viewer),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return map[string]interface{}{"id": c.ApplicationID, "type": "APPLICATION"}, nil
+					},
+				},
+				"application": &graphql.Field{
+					Type: application,
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					},
+					Resolve: c.resolveApplication,
+				},
+			},
+		}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Mutation",
+			Fields: graphql.Fields{
+				"setApplicationLabel": &graphql.Field{
+					Type: graphql.NewNonNull(label),
+					Args: graphql.FieldConfigArgument{
+						"applicationID": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"key":           &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+						"value":         &graphql.ArgumentConfig{Type: graphql.NewNonNull(anyValue)},
+					},
+					Resolve: c.resolveSetApplicationLabel,
+				},
+				"addPackage": &graphql.Field{
+					Type: graphql.NewNonNull(packageType),
+					Args: graphql.FieldConfigArgument{
+						"applicationID": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"in":            &graphql.ArgumentConfig{Type: graphql.NewNonNull(packageCreateInput)},
+					},
+					Resolve: c.resolveAddPackage,
+				},
+				"deletePackage": &graphql.Field{
+					Type: graphql.NewNonNull(packageType),
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					},
+					Resolve: c.resolveDeletePackage,
+				},
+				"addAPIDefinitionToPackage": &graphql.Field{
+					Type: graphql.NewNonNull(apiDefinition),
+					Args: graphql.FieldConfigArgument{
+						"packageID": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"in":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(apiDefinitionInput)},
+					},
+					Resolve: c.resolveAddAPIDefinition,
+				},
+				"addEventDefinitionToPackage": &graphql.Field{
+					Type: graphql.NewNonNull(eventDefinition),
+					Args: graphql.FieldConfigArgument{
+						"packageID": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"in":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(eventDefinitionInput)},
+					},
+					Resolve: c.resolveAddEventDefinition,
+				},
+				"updateAPIDefinition": &graphql.Field{
+					Type: graphql.NewNonNull(apiDefinition),
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"in": &graphql.ArgumentConfig{Type: graphql.NewNonNull(apiDefinitionInput)},
+					},
+					Resolve: c.resolveUpdateAPIDefinition,
+				},
+				"updateEventDefinition": &graphql.Field{
+					Type: graphql.NewNonNull(eventDefinition),
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+						"in": &graphql.ArgumentConfig{Type: graphql.NewNonNull(eventDefinitionInput)},
+					},
+					Resolve: c.resolveUpdateEventDefinition,
+				},
+				"deleteAPIDefinition": &graphql.Field{
+					Type: graphql.NewNonNull(apiDefinition),
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					},
+					Resolve: c.resolveDeleteAPIDefinition,
+				},
+				"deleteEventDefinition": &graphql.Field{
+					Type: graphql.NewNonNull(eventDefinition),
+					Args: graphql.FieldConfigArgument{
+						"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					},
+					Resolve: c.resolveDeleteEventDefinition,
+				},
+			},
+		}),
+	}
+}
+
+func (c *Compass) resolveConfiguration(p graphql.ResolveParams) (interface{}, error) {
+	if err := c.authenticate(p.Context); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"token": map[string]interface{}{"token": c.newConnectorToken()},
+		"certificateSigningRequestInfo": map[string]interface{}{
+			"subject":      c.subject(),
+			"keyAlgorithm": c.KeyAlgorithm,
+		},
+		"managementPlaneInfo": map[string]interface{}{
+			"directorURL":                    c.GatewayURL + "/director/graphql",
+			"certificateSecuredConnectorURL": c.GatewayURL + "/connector/graphql",
+		},
+	}, nil
+}
+
+func (c *Compass) resolveSignCSR(p graphql.ResolveParams) (interface{}, error) {
+	if err := c.authenticate(p.Context); err != nil {
+		return nil, err
+	}
+
+	csrPEM, err := base64.StdEncoding.DecodeString(p.Args["csr"].(string))
+	if err != nil {
+		return nil, errors.New("csr is not base64 encoded")
+	}
+
+	clientCert, clientCertPEM, err := c.CA.SignCSR(csrPEM, c.CertificateValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientCert.Subject.CommonName != c.ApplicationID {
+		return nil, fmt.Errorf("csr common name %q does not match the application id", clientCert.Subject.CommonName)
+	}
+
+	crtChain := append(append([]byte{}, clientCertPEM...), c.CA.CertPEM()...)
+
+	return map[string]interface{}{
+		"certificateChain":  base64.StdEncoding.EncodeToString(crtChain),
+		"caCertificate":     base64.StdEncoding.EncodeToString(c.CA.CertPEM()),
+		"clientCertificate": base64.StdEncoding.EncodeToString(clientCertPEM),
+	}, nil
+}
+
+func (c *Compass) resolveRevokeCertificate(p graphql.ResolveParams) (interface{}, error) {
+	clientCert := peerCertificate(p.Context.Value(requestKey).(*http.Request))
+	if clientCert == nil {
+		return nil, errors.New("client certificate required")
+	}
+
+	c.auth.revoke(clientCert)
+	return true, nil
+}
+
+func (c *Compass) resolveApplication(p graphql.ResolveParams) (interface{}, error) {
+	if p.Args["id"].(string) != c.ApplicationID {
+		return nil, nil
+	}
+
+	return map[string]interface{}{
+		"id":     c.ApplicationID,
+		"name":   c.ApplicationName,
+		"labels": c.applicationLabels(),
+		"eventingConfiguration": map[string]interface{}{
+			"defaultURL": fmt.Sprintf("%s/%s/v1/events", c.GatewayURL, c.ApplicationName),
+		},
+	}, nil
+}
+
+func (c *Compass) resolveSetApplicationLabel(p graphql.ResolveParams) (interface{}, error) {
+	if p.Args["applicationID"].(string) != c.ApplicationID {
+		return nil, errors.New("application not found")
+	}
+
+	key := p.Args["key"].(string)
+
+	c.mu.Lock()
+	c.labels[key] = p.Args["value"]
+	c.mu.Unlock()
+
+	return map[string]interface{}{"key": key, "value": p.Args["value"]}, nil
+}
+
+func (c *Compass) applicationLabels() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	labels := map[string]interface{}{}
+	for key, value := range c.labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+func (c *Compass) resolveAddPackage(p graphql.ResolveParams) (interface{}, error) {
+	if p.Args["applicationID"].(string) != c.ApplicationID {
+		return nil, errors.New("application not found")
+	}
+
+	in := p.Args["in"].(map[string]interface{})
+
+	pkg := &compassPackage{
+		ID:                  newUUID(),
+		Name:                stringArg(in, "name"),
+		Description:         stringArg(in, "description"),
+		DefaultInstanceAuth: in["defaultInstanceAuth"],
+	}
+
+	c.mu.Lock()
+	c.packages[pkg.ID] = pkg
+	c.order = append(c.order, pkg.ID)
+	c.mu.Unlock()
+
+	log.Printf("fake compass: added package %s (%s)", pkg.Name, pkg.ID)
+	return pkg, nil
+}
+
+func (c *Compass) resolveDeletePackage(p graphql.ResolveParams) (interface{}, error) {
+	id := p.Args["id"].(string)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, ok := c.packages[id]
+	if !ok {
+		return nil, errors.New("package not found")
+	}
+
+	delete(c.packages, id)
+	for i := range c.order {
+		if c.order[i] == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+
+	log.Printf("fake compass: deleted package %s (%s)", pkg.Name, pkg.ID)
+	return pkg, nil
+}
+
+func (c *Compass) resolveAddAPIDefinition(p graphql.ResolveParams) (interface{}, error) {
+	in := p.Args["in"].(map[string]interface{})
+
+	apiDef := &compassAPIDefinition{
+		ID:          newUUID(),
+		Name:        stringArg(in, "name"),
+		Description: stringArg(in, "description"),
+		TargetURL:   stringArg(in, "targetURL"),
+		Spec:        specArg(in),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, ok := c.packages[p.Args["packageID"].(string)]
+	if !ok {
+		return nil, errors.New("package not found")
+	}
+	pkg.APIDefinitions = append(pkg.APIDefinitions, apiDef)
+
+	return apiDef, nil
+}
+
+func (c *Compass) resolveAddEventDefinition(p graphql.ResolveParams) (interface{}, error) {
+	in := p.Args["in"].(map[string]interface{})
+
+	eventDef := &compassEventDefinition{
+		ID:          newUUID(),
+		Name:        stringArg(in, "name"),
+		Description: stringArg(in, "description"),
+		Spec:        specArg(in),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, ok := c.packages[p.Args["packageID"].(string)]
+	if !ok {
+		return nil, errors.New("package not found")
+	}
+	pkg.EventDefinitions = append(pkg.EventDefinitions, eventDef)
+
+	return eventDef, nil
+}
+
+func (c *Compass) resolveUpdateAPIDefinition(p graphql.ResolveParams) (interface{}, error) {
+	in := p.Args["in"].(map[string]interface{})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, i := c.findAPIDefinition(p.Args["id"].(string))
+	if pkg == nil {
+		return nil, errors.New("api definition not found")
+	}
+
+	apiDef := &compassAPIDefinition{
+		ID:          pkg.APIDefinitions[i].ID,
+		Name:        stringArg(in, "name"),
+		Description: stringArg(in, "description"),
+		TargetURL:   stringArg(in, "targetURL"),
+		Spec:        specArg(in),
+	}
+	pkg.APIDefinitions[i] = apiDef
+
+	return apiDef, nil
+}
+
+func (c *Compass) resolveUpdateEventDefinition(p graphql.ResolveParams) (interface{}, error) {
+	in := p.Args["in"].(map[string]interface{})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, i := c.findEventDefinition(p.Args["id"].(string))
+	if pkg == nil {
+		return nil, errors.New("event definition not found")
+	}
+
+	eventDef := &compassEventDefinition{
+		ID:          pkg.EventDefinitions[i].ID,
+		Name:        stringArg(in, "name"),
+		Description: stringArg(in, "description"),
+		Spec:        specArg(in),
+	}
+	pkg.EventDefinitions[i] = eventDef
+
+	return eventDef, nil
+}
+
+func (c *Compass) resolveDeleteAPIDefinition(p graphql.ResolveParams) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, i := c.findAPIDefinition(p.Args["id"].(string))
+	if pkg == nil {
+		return nil, errors.New("api definition not found")
+	}
+
+	apiDef := pkg.APIDefinitions[i]
+	pkg.APIDefinitions = append(pkg.APIDefinitions[:i], pkg.APIDefinitions[i+1:]...)
+
+	return apiDef, nil
+}
+
+func (c *Compass) resolveDeleteEventDefinition(p graphql.ResolveParams) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pkg, i := c.findEventDefinition(p.Args["id"].(string))
+	if pkg == nil {
+		return nil, errors.New("event definition not found")
+	}
+
+	eventDef := pkg.EventDefinitions[i]
+	pkg.EventDefinitions = append(pkg.EventDefinitions[:i], pkg.EventDefinitions[i+1:]...)
+
+	return eventDef, nil
+}
+
+//the package containing the api definition and its index, c.mu must be held
+func (c *Compass) findAPIDefinition(id string) (*compassPackage, int) {
+	for _, pkg := range c.packages {
+		for i, apiDef := range pkg.APIDefinitions {
+			if apiDef.ID == id {
+				return pkg, i
+			}
+		}
+	}
+	return nil, 0
+}
+
+//the package containing the event definition and its index, c.mu must be held
+func (c *Compass) findEventDefinition(id string) (*compassPackage, int) {
+	for _, pkg := range c.packages {
+		for i, eventDef := range pkg.EventDefinitions {
+			if eventDef.ID == id {
+				return pkg, i
+			}
+		}
+	}
+	return nil, 0
+}
+
+func (c *Compass) listPackages() []*compassPackage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	packages := []*compassPackage{}
+	for _, id := range c.order {
+		packages = append(packages, c.packages[id])
+	}
+	return packages
+}
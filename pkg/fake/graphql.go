@@ -0,0 +1,123 @@
+package fake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+//serves a schema, the request is available to the resolvers via the context
+func graphQLHandler(schema graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var gqlReq struct {
+			Query         string                 `json:"query"`
+			Variables     map[string]interface{} `json:"variables"`
+			OperationName string                 `json:"operationName"`
+		}
+		if err := readJSON(r, &gqlReq); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid graphql request")
+			return
+		}
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  gqlReq.Query,
+			VariableValues: gqlReq.Variables,
+			OperationName:  gqlReq.OperationName,
+			Context:        context.WithValue(r.Context(), requestKey, r),
+		})
+
+		writeJSON(w, http.StatusOK, result)
+	})
+}
+
+//scalar passing through any json value, used for CLOB and the untyped auth inputs
+func newJSONScalar(name string) *graphql.Scalar {
+	identity := func(value interface{}) interface{} { return value }
+
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:         name,
+		Serialize:    identity,
+		ParseValue:   identity,
+		ParseLiteral: valueFromAST,
+	})
+}
+
+func valueFromAST(valueAST ast.Value) interface{} {
+	switch value := valueAST.(type) {
+	case *ast.ObjectValue:
+		object := map[string]interface{}{}
+		for _, field := range value.Fields {
+			object[field.Name.Value] = valueFromAST(field.Value)
+		}
+		return object
+	case *ast.ListValue:
+		list := []interface{}{}
+		for _, item := range value.Values {
+			list = append(list, valueFromAST(item))
+		}
+		return list
+	default:
+		return valueAST.GetValue()
+	}
+}
+
+func pageOf(name string, itemType graphql.Output) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"data":       &graphql.Field{Type: graphql.NewList(itemType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+}
+
+//wraps a slice in a compass page
+func newPage(items interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"data":       items,
+		"totalCount": reflect.ValueOf(items).Len(),
+	}
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+func specArg(args map[string]interface{}) *compassSpec {
+	spec, ok := args["spec"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	data := spec["data"]
+	if _, isString := data.(string); !isString && data != nil {
+		//specs sent as json objects are stored in their serialized form
+		serialized, _ := json.Marshal(data)
+		data = string(serialized)
+	}
+	dataStr, _ := data.(string)
+
+	return &compassSpec{
+		Data:   dataStr,
+		Type:   stringArg(spec, "type"),
+		Format: stringArg(spec, "format"),
+	}
+}
+
+func newUUID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+}
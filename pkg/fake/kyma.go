@@ -0,0 +1,470 @@
+package fake
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+//DefaultCertificateValidity - lifetime of the client certificates issued by the fakes
+const DefaultCertificateValidity = 24 * time.Hour
+
+//DefaultKeyAlgorithm - key algorithm advertised by the fakes
+const DefaultKeyAlgorithm = "rsa2048"
+
+//Kyma - local stand-in for the REST application connector, metadata service and events gateway of kyma
+//the connector endpoints (token, csr signing) are plain http, all others require a client certificate
+type Kyma struct {
+	Application         string
+	CertificateValidity time.Duration
+	//key algorithm advertised for the csr, e.g. rsa2048 or ecdsa-p256
+	KeyAlgorithm string
+	//extensions advertised for the csr, e.g. extendedKeyUsage=clientAuth
+	CertificateExtensions string
+	CA                    *CA
+
+	//base url of the server running ConnectorHandler
+	ConnectorURL string
+	//base url of the server running GatewayHandler
+	GatewayURL string
+
+	mu       sync.Mutex
+	tokens   map[string]bool
+	services map[string]json.RawMessage
+	order    []string
+	events   eventLog
+	auth     *clientAuth
+}
+
+//KymaTestServer - a Kyma running on two httptest servers
+type KymaTestServer struct {
+	*Kyma
+	Connector *httptest.Server
+	Gateway   *httptest.Server
+}
+
+//NewKyma - creates the fake for the given application name
+func NewKyma(application string) (*Kyma, error) {
+
+	ca, err := NewCA("Kyma Fake CA")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Kyma{
+		Application:         application,
+		CertificateValidity: DefaultCertificateValidity,
+		KeyAlgorithm:        DefaultKeyAlgorithm,
+		CA:                  ca,
+		tokens:              map[string]bool{},
+		services:            map[string]json.RawMessage{},
+		auth:                newClientAuth(application),
+	}, nil
+}
+
+//NewKymaTestServer - starts the connector and the gateway on local httptest servers
+func NewKymaTestServer(application string) (*KymaTestServer, error) {
+
+	kyma, err := NewKyma(application)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, gateway, err := startTestServers(kyma.CA, kyma.ConnectorHandler(), kyma.GatewayHandler())
+	if err != nil {
+		return nil, err
+	}
+
+	kyma.ConnectorURL = connector.URL
+	kyma.GatewayURL = gateway.URL
+
+	return &KymaTestServer{Kyma: kyma, Connector: connector, Gateway: gateway}, nil
+}
+
+//ListenAndServe - runs the connector on connectorAddr and the gateway on gatewayAddr, host is used in the advertised urls
+//a first token url is logged once both listeners are bound
+func (k *Kyma) ListenAndServe(host string, connectorAddr string, gatewayAddr string) error {
+
+	return listenAndServe(k.CA, host, connectorAddr, gatewayAddr, k.ConnectorHandler(), k.GatewayHandler(), func(connectorURL string, gatewayURL string) {
+		k.ConnectorURL = connectorURL
+		k.GatewayURL = gatewayURL
+
+		log.Printf("fake kyma: application %s, token url: %s", k.Application, k.NewToken())
+		log.Printf("fake kyma: new tokens via POST %s/v1/applications/tokens", k.ConnectorURL)
+	})
+}
+
+//Close - shuts down both servers
+func (s *KymaTestServer) Close() {
+	s.Connector.Close()
+	s.Gateway.Close()
+}
+
+//NewToken - returns a one time token url accepted by CallTokenURL
+func (k *Kyma) NewToken() string {
+	token := newToken()
+
+	k.mu.Lock()
+	k.tokens[token] = true
+	k.mu.Unlock()
+
+	return fmt.Sprintf("%s/v1/applications/signingRequests/info?token=%s", k.ConnectorURL, token)
+}
+
+//Services - the registered services by id
+func (k *Kyma) Services() map[string]json.RawMessage {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	services := map[string]json.RawMessage{}
+	for id, service := range k.services {
+		services[id] = service
+	}
+	return services
+}
+
+//Events - the events received so far
+func (k *Kyma) Events() []json.RawMessage {
+	return k.events.list()
+}
+
+//ConnectorHandler - token info and csr signing, authenticated with the one time token
+func (k *Kyma) ConnectorHandler() http.Handler {
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/applications/tokens", k.createToken).Methods("POST")
+	router.HandleFunc("/v1/applications/signingRequests/info", k.signingRequestInfo).Methods("GET")
+	router.HandleFunc("/v1/applications/certificates", k.signCertificate).Methods("POST")
+	return router
+}
+
+//GatewayHandler - management info, certificate renewal and revocation, metadata and events, authenticated with the client certificate
+func (k *Kyma) GatewayHandler() http.Handler {
+	router := mux.NewRouter()
+	router.Use(k.auth.requireClientCertificate)
+	router.HandleFunc("/v1/applications/management/info", k.managementInfo).Methods("GET")
+	router.HandleFunc("/v1/applications/certificates/renewals", k.renewCertificate).Methods("POST")
+	router.HandleFunc("/v1/applications/certificates/revocations", k.revokeCertificate).Methods("POST")
+
+	app := router.PathPrefix("/{application}/v1").Subrouter()
+	app.HandleFunc("/metadata/services", k.listServices).Methods("GET")
+	app.HandleFunc("/metadata/services", k.createService).Methods("POST")
+	app.HandleFunc("/metadata/services/{id}", k.getService).Methods("GET")
+	app.HandleFunc("/metadata/services/{id}", k.updateService).Methods("PUT")
+	app.HandleFunc("/metadata/services/{id}", k.deleteService).Methods("DELETE")
+	app.HandleFunc("/events", k.events.publishLegacyEvent).Methods("POST")
+	router.HandleFunc("/{application}/v2/events", k.events.publishCloudEvent).Methods("POST")
+	router.HandleFunc("/{application}/events", k.events.publishCloudEvent).Methods("POST")
+	return router
+}
+
+func (k *Kyma) subject() string {
+	return fmt.Sprintf("OU=OrgUnit,O=Organization,L=Waldorf,ST=Waldorf,C=DE,CN=%s", k.Application)
+}
+
+func (k *Kyma) certificateInfo() map[string]string {
+	return map[string]string{
+		"subject":       k.subject(),
+		"extensions":    k.CertificateExtensions,
+		"key-algorithm": k.KeyAlgorithm,
+	}
+}
+
+func (k *Kyma) metadataURL() string {
+	return fmt.Sprintf("%s/%s/v1/metadata/services", k.GatewayURL, k.Application)
+}
+
+func (k *Kyma) eventsURL() string {
+	return fmt.Sprintf("%s/%s/v1/events", k.GatewayURL, k.Application)
+}
+
+//checks the one time token, it stays valid until a certificate was issued
+func (k *Kyma) validToken(r *http.Request) (string, bool) {
+	token := r.URL.Query().Get("token")
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return token, token != "" && k.tokens[token]
+}
+
+func (k *Kyma) createToken(w http.ResponseWriter, r *http.Request) {
+	tokenURL := k.NewToken()
+	writeJSON(w, http.StatusCreated, map[string]string{"url": tokenURL})
+}
+
+func (k *Kyma) signingRequestInfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := k.validToken(r)
+	if !ok {
+		writeError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"csrUrl": fmt.Sprintf("%s/v1/applications/certificates?token=%s", k.ConnectorURL, token),
+		"api": map[string]string{
+			"metadataUrl":     k.metadataURL(),
+			"eventsUrl":       k.eventsURL(),
+			"infoUrl":         k.GatewayURL + "/v1/applications/management/info",
+			"certificatesUrl": k.ConnectorURL + "/v1/applications/certificates",
+		},
+		"certificate": k.certificateInfo(),
+	})
+}
+
+func (k *Kyma) signCertificate(w http.ResponseWriter, r *http.Request) {
+	token, ok := k.validToken(r)
+	if !ok {
+		writeError(w, http.StatusForbidden, "invalid token")
+		return
+	}
+
+	if !k.issueCertificate(w, r) {
+		return
+	}
+
+	k.mu.Lock()
+	delete(k.tokens, token)
+	k.mu.Unlock()
+}
+
+func (k *Kyma) renewCertificate(w http.ResponseWriter, r *http.Request) {
+	k.issueCertificate(w, r)
+}
+
+//signs the csr in the request body and writes the crt, clientCrt and caCrt response
+func (k *Kyma) issueCertificate(w http.ResponseWriter, r *http.Request) bool {
+	var csrReq struct {
+		CSR string `json:"csr"`
+	}
+	if err := readJSON(r, &csrReq); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	csrPEM, err := base64.StdEncoding.DecodeString(csrReq.CSR)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "csr is not base64 encoded")
+		return false
+	}
+
+	clientCert, clientCertPEM, err := k.CA.SignCSR(csrPEM, k.CertificateValidity)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	if clientCert.Subject.CommonName != k.Application {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("csr common name %q does not match the application", clientCert.Subject.CommonName))
+		return false
+	}
+
+	crtChain := append(append([]byte{}, clientCertPEM...), k.CA.CertPEM()...)
+
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"crt":       base64.StdEncoding.EncodeToString(crtChain),
+		"clientCrt": base64.StdEncoding.EncodeToString(clientCertPEM),
+		"caCrt":     base64.StdEncoding.EncodeToString(k.CA.CertPEM()),
+	})
+	return true
+}
+
+func (k *Kyma) managementInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"clientIdentity": map[string]string{
+			"application": k.Application,
+		},
+		"urls": map[string]string{
+			"metadataUrl":   k.metadataURL(),
+			"eventsUrl":     k.eventsURL(),
+			"renewCertUrl":  k.GatewayURL + "/v1/applications/certificates/renewals",
+			"revokeCertUrl": k.GatewayURL + "/v1/applications/certificates/revocations",
+		},
+		"certificate": k.certificateInfo(),
+	})
+}
+
+func (k *Kyma) revokeCertificate(w http.ResponseWriter, r *http.Request) {
+	k.auth.revoke(peerCertificate(r))
+	w.WriteHeader(http.StatusCreated)
+}
+
+//service details as accepted by the metadata service
+type kymaService struct {
+	Provider    string            `json:"provider"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Identifier  string            `json:"identifier,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	API         json.RawMessage   `json:"api,omitempty"`
+	Events      json.RawMessage   `json:"events,omitempty"`
+}
+
+func (k *Kyma) listServices(w http.ResponseWriter, r *http.Request) {
+	if !k.validApplication(w, r) {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	type serviceSummary struct {
+		ID          string            `json:"id"`
+		Provider    string            `json:"provider"`
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		Identifier  string            `json:"identifier,omitempty"`
+		Labels      map[string]string `json:"labels,omitempty"`
+	}
+
+	summaries := []serviceSummary{}
+	for _, id := range k.order {
+		var service kymaService
+		json.Unmarshal(k.services[id], &service)
+		summaries = append(summaries, serviceSummary{
+			ID:          id,
+			Provider:    service.Provider,
+			Name:        service.Name,
+			Description: service.Description,
+			Identifier:  service.Identifier,
+			Labels:      service.Labels,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (k *Kyma) createService(w http.ResponseWriter, r *http.Request) {
+	if !k.validApplication(w, r) {
+		return
+	}
+
+	body, ok := readService(w, r)
+	if !ok {
+		return
+	}
+
+	id := newToken()
+
+	k.mu.Lock()
+	k.services[id] = body
+	k.order = append(k.order, id)
+	k.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{"id": id})
+}
+
+func (k *Kyma) getService(w http.ResponseWriter, r *http.Request) {
+	if !k.validApplication(w, r) {
+		return
+	}
+
+	k.mu.Lock()
+	service, ok := k.services[mux.Vars(r)["id"]]
+	k.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "service not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(service)
+}
+
+func (k *Kyma) updateService(w http.ResponseWriter, r *http.Request) {
+	if !k.validApplication(w, r) {
+		return
+	}
+
+	body, ok := readService(w, r)
+	if !ok {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	k.mu.Lock()
+	_, exists := k.services[id]
+	if exists {
+		k.services[id] = body
+	}
+	k.mu.Unlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, "service not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (k *Kyma) deleteService(w http.ResponseWriter, r *http.Request) {
+	if !k.validApplication(w, r) {
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	k.mu.Lock()
+	_, exists := k.services[id]
+	delete(k.services, id)
+	for i := range k.order {
+		if k.order[i] == id {
+			k.order = append(k.order[:i], k.order[i+1:]...)
+			break
+		}
+	}
+	k.mu.Unlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, "service not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//only the application the client certificate was issued for is accessible
+func (k *Kyma) validApplication(w http.ResponseWriter, r *http.Request) bool {
+	if mux.Vars(r)["application"] != k.Application {
+		writeError(w, http.StatusNotFound, "application not found")
+		return false
+	}
+	return true
+}
+
+func readService(w http.ResponseWriter, r *http.Request) (json.RawMessage, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	var service kymaService
+	if err := json.Unmarshal(body, &service); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid service details")
+		return nil, false
+	}
+
+	if service.Provider == "" || service.Name == "" {
+		writeError(w, http.StatusBadRequest, "provider and name are required")
+		return nil, false
+	}
+
+	if len(service.API) == 0 && len(service.Events) == 0 {
+		writeError(w, http.StatusBadRequest, "api or events is required")
+		return nil, false
+	}
+
+	return body, true
+}
+
@@ -0,0 +1,237 @@
+package fake
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+//starts the plain connector server and the tls gateway server on local httptest servers
+func startTestServers(ca *CA, connectorHandler http.Handler, gatewayHandler http.Handler) (*httptest.Server, *httptest.Server, error) {
+
+	tlsConfig, err := ca.ServerTLSConfig("127.0.0.1", "localhost")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	connector := httptest.NewServer(connectorHandler)
+
+	gateway := httptest.NewUnstartedServer(gatewayHandler)
+	gateway.TLS = tlsConfig
+	gateway.StartTLS()
+
+	return connector, gateway, nil
+}
+
+//binds both addresses, reports the advertised base urls and serves until one of the servers fails
+func listenAndServe(ca *CA, host string, connectorAddr string, gatewayAddr string, connectorHandler http.Handler, gatewayHandler http.Handler, ready func(connectorURL string, gatewayURL string)) error {
+
+	tlsConfig, err := ca.ServerTLSConfig(host, "localhost", "127.0.0.1")
+	if err != nil {
+		return err
+	}
+
+	connectorListener, err := net.Listen("tcp", connectorAddr)
+	if err != nil {
+		return err
+	}
+
+	gatewayListener, err := net.Listen("tcp", gatewayAddr)
+	if err != nil {
+		connectorListener.Close()
+		return err
+	}
+
+	ready("http://"+advertisedAddr(host, connectorListener.Addr().String()), "https://"+advertisedAddr(host, gatewayListener.Addr().String()))
+
+	errs := make(chan error, 2)
+	go func() { errs <- http.Serve(tls.NewListener(gatewayListener, tlsConfig), gatewayHandler) }()
+	go func() { errs <- http.Serve(connectorListener, connectorHandler) }()
+
+	return <-errs
+}
+
+//the address clients use to reach a server listening on addr
+func advertisedAddr(host string, addr string) string {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+//clientAuth - accepts client certificates issued for commonName that have not been revoked
+type clientAuth struct {
+	commonName string
+
+	mu      sync.Mutex
+	revoked map[string]bool
+}
+
+func newClientAuth(commonName string) *clientAuth {
+	return &clientAuth{
+		commonName: commonName,
+		revoked:    map[string]bool{},
+	}
+}
+
+func (a *clientAuth) revoke(clientCert *x509.Certificate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.revoked[clientCert.SerialNumber.String()] = true
+}
+
+func (a *clientAuth) requireClientCertificate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCert := peerCertificate(r)
+		if clientCert == nil {
+			writeError(w, http.StatusForbidden, "client certificate required")
+			return
+		}
+
+		a.mu.Lock()
+		revoked := a.revoked[clientCert.SerialNumber.String()]
+		a.mu.Unlock()
+
+		if revoked {
+			writeError(w, http.StatusForbidden, "client certificate has been revoked")
+			return
+		}
+
+		if clientCert.Subject.CommonName != a.commonName {
+			writeError(w, http.StatusForbidden, "client certificate was not issued for this application")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+//the verified client certificate of the request
+func peerCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+//eventLog - records the events received by the legacy and the cloud events endpoints
+type eventLog struct {
+	mu     sync.Mutex
+	events []json.RawMessage
+}
+
+func (l *eventLog) list() []json.RawMessage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]json.RawMessage{}, l.events...)
+}
+
+//accepts events in the event-type / event-type-version format
+func (l *eventLog) publishLegacyEvent(w http.ResponseWriter, r *http.Request) {
+
+	var event struct {
+		EventType        string          `json:"event-type"`
+		EventTypeVersion string          `json:"event-type-version"`
+		EventID          string          `json:"event-id"`
+		EventTime        string          `json:"event-time"`
+		Data             json.RawMessage `json:"data"`
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &event)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid event")
+		return
+	}
+
+	if event.EventType == "" || event.EventTypeVersion == "" || event.EventTime == "" || len(event.Data) == 0 {
+		writeError(w, http.StatusBadRequest, "event-type, event-type-version, event-time and data are required")
+		return
+	}
+
+	if event.EventID == "" {
+		event.EventID = newToken()
+	}
+
+	l.mu.Lock()
+	l.events = append(l.events, body)
+	l.mu.Unlock()
+
+	log.Printf("fake: received event %s %s", event.EventType, event.EventTypeVersion)
+	writeJSON(w, http.StatusOK, map[string]string{"event-id": event.EventID})
+}
+
+//accepts CloudEvents 1.0 in structured (application/cloudevents+json) or binary (ce-* headers) mode,
+//binary events are recorded in the structured form
+func (l *eventLog) publishCloudEvent(w http.ResponseWriter, r *http.Request) {
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid event")
+		return
+	}
+
+	event := map[string]interface{}{}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case contentType == "application/cloudevents+json":
+		if err := json.Unmarshal(body, &event); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid structured cloud event")
+			return
+		}
+	case r.Header.Get("ce-specversion") != "":
+		for name, values := range r.Header {
+			if name = strings.ToLower(name); strings.HasPrefix(name, "ce-") {
+				event[strings.TrimPrefix(name, "ce-")] = values[0]
+			}
+		}
+		if contentType != "" {
+			event["datacontenttype"] = contentType
+		}
+		if json.Valid(body) {
+			event["data"] = json.RawMessage(body)
+		} else {
+			event["data"] = string(body)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "a structured or binary cloud event is required")
+		return
+	}
+
+	for _, attribute := range []string{"specversion", "id", "source", "type"} {
+		if value, _ := event[attribute].(string); value == "" {
+			writeError(w, http.StatusBadRequest, attribute+" is required")
+			return
+		}
+	}
+	if event["specversion"] != "1.0" {
+		writeError(w, http.StatusBadRequest, "only specversion 1.0 is supported")
+		return
+	}
+
+	recorded, err := json.Marshal(event)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid event")
+		return
+	}
+
+	l.mu.Lock()
+	l.events = append(l.events, recorded)
+	l.mu.Unlock()
+
+	log.Printf("fake: received cloud event %s from %s", event["type"], event["source"])
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,312 @@
+package mock
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+)
+
+//AuthModeEnv - none disables the protection of the mock api
+const AuthModeEnv string = "APP_CONN_MOCK_AUTH"
+
+const authModeNone string = "none"
+const defaultClientID string = "kyma-app-conn-demo"
+
+//headers and query params the gateway adds by default, they match the request parameters of api-rest.json
+const defaultHeader string = "CustomHeader"
+const defaultHeaderValue string = "customvalue"
+const defaultQueryParam string = "qp1"
+const defaultQueryParamValue string = "qp1Value"
+
+//the auth and its resolved secrets, read once and replaced by the auth registered last
+var mockAuth struct {
+	once     sync.Once
+	mu       sync.RWMutex
+	auth     *connector.AuthConfig
+	expected *expectedAuth
+	err      error
+}
+
+//the credentials, headers and query params a call must carry
+type expectedAuth struct {
+	basicUsername string
+	basicPassword string
+	clientID      string
+	clientSecret  string
+	headers       map[string]string
+	queryParams   map[string]string
+	csrf          bool
+}
+
+type authError struct {
+	Error   string   `json:"error"`
+	Reasons []string `json:"reasons"`
+}
+
+func loadAuth() {
+	mockAuth.once.Do(func() {
+		if os.Getenv(AuthModeEnv) == authModeNone {
+			return
+		}
+
+		auth, err := connector.ConfiguredAuth()
+		if err == nil && auth == nil {
+			auth, err = defaultAuth()
+		}
+		if err != nil {
+			mockAuth.err = err
+			return
+		}
+
+		mockAuth.err = setAuth(*auth)
+	})
+}
+
+//UseAuth - makes the mock api expect the auth, e.g. the default instance auth registered with a compass package,
+//so the gateway is checked against the credentials it was actually given. Auth returns it from now on
+func UseAuth(auth connector.AuthConfig) error {
+	loadAuth()
+
+	if os.Getenv(AuthModeEnv) == authModeNone {
+		return nil
+	}
+	if err := setAuth(auth); err != nil {
+		return err
+	}
+	log.Println("The mock api expects the auth registered last")
+	return nil
+}
+
+//resolves the secrets and replaces the expected auth, the current one is kept if a secret cannot be resolved
+func setAuth(auth connector.AuthConfig) error {
+
+	if csrfEnabled() && auth.CSRF == nil {
+		auth.CSRF = &connector.CSRFConfig{TokenEndpointURL: CSRFPath}
+	}
+
+	expected, err := resolveAuth(auth)
+	if err != nil {
+		return fmt.Errorf("auth of the mock api: %s", err)
+	}
+
+	mockAuth.mu.Lock()
+	defer mockAuth.mu.Unlock()
+
+	mockAuth.auth = &auth
+	mockAuth.expected = expected
+	mockAuth.err = nil
+	return nil
+}
+
+//the auth, its resolved secrets and the error of loading it
+func currentAuth() (*connector.AuthConfig, *expectedAuth, error) {
+	loadAuth()
+
+	mockAuth.mu.RLock()
+	defer mockAuth.mu.RUnlock()
+
+	return mockAuth.auth, mockAuth.expected, mockAuth.err
+}
+
+//oauth client credentials, a header and a query param
+//without APP_CONN_MOCK_CLIENT_SECRET the secret is generated, a well known secret must not end up in customer tenants
+func defaultAuth() (*connector.AuthConfig, error) {
+	clientID, clientSecret := os.Getenv(ClientIDEnv), os.Getenv(ClientSecretEnv)
+	if clientID == "" {
+		clientID = defaultClientID
+	}
+	if clientSecret == "" {
+		var err error
+		if clientSecret, err = randomHex(32); err != nil {
+			return nil, err
+		}
+		log.Printf("Generated the client secret of the mock api, set %s to keep it across restarts", ClientSecretEnv)
+	}
+
+	return &connector.AuthConfig{
+		OAuth: &connector.OAuthCredential{
+			ClientID:     clientID,
+			ClientSecret: connector.Secret{Value: clientSecret},
+			URL:          TokenPath,
+		},
+		Headers:     map[string]connector.Secret{defaultHeader: {Value: defaultHeaderValue}},
+		QueryParams: map[string]connector.Secret{defaultQueryParam: {Value: defaultQueryParamValue}},
+	}, nil
+}
+
+func resolveAuth(auth connector.AuthConfig) (*expectedAuth, error) {
+
+	expected := &expectedAuth{headers: map[string]string{}, queryParams: map[string]string{}, csrf: auth.CSRF != nil}
+	var err error
+
+	if auth.Basic != nil {
+		expected.basicUsername = auth.Basic.Username
+		if expected.basicPassword, err = auth.Basic.Password.Resolve(); err != nil {
+			return nil, fmt.Errorf("basic.password: %s", err)
+		}
+	}
+	if auth.OAuth != nil {
+		expected.clientID = auth.OAuth.ClientID
+		if expected.clientSecret, err = auth.OAuth.ClientSecret.Resolve(); err != nil {
+			return nil, fmt.Errorf("oauth.clientSecret: %s", err)
+		}
+	}
+	for name, secret := range auth.Headers {
+		if expected.headers[name], err = secret.Resolve(); err != nil {
+			return nil, fmt.Errorf("headers.%s: %s", name, err)
+		}
+	}
+	for name, secret := range auth.QueryParams {
+		if expected.queryParams[name], err = secret.Resolve(); err != nil {
+			return nil, fmt.Errorf("queryParams.%s: %s", name, err)
+		}
+	}
+	return expected, nil
+}
+
+//Auth - the credentials, headers and query params the mock api expects, nil if it is not protected
+//the default instance auth of APP_CONN_PACKAGE_CONFIG if set, else oauth client credentials of the token endpoint
+//with a generated secret, CustomHeader and qp1, csrf is added if enabled. token urls are relative to the target url the api is registered with
+func Auth() *connector.AuthConfig {
+	auth, _, _ := currentAuth()
+	return auth
+}
+
+func equal(actual string, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
+
+//the reasons a call is refused, credentials are checked first as their failures are 401
+func (expected *expectedAuth) check(r *http.Request) (int, []string) {
+
+	switch {
+	case expected.basicUsername != "":
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			return http.StatusUnauthorized, []string{"basic auth is required"}
+		}
+		if !equal(username, expected.basicUsername) || !equal(password, expected.basicPassword) {
+			return http.StatusUnauthorized, []string{fmt.Sprintf("wrong username or password for user %q", username)}
+		}
+	case expected.clientID != "":
+		authorization := r.Header.Get("Authorization")
+		if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "bearer ") {
+			return http.StatusUnauthorized, []string{"a bearer token of " + TokenPath + " is required"}
+		}
+		if !validToken(strings.TrimSpace(authorization[7:])) {
+			return http.StatusUnauthorized, []string{"the bearer token is invalid or expired"}
+		}
+	}
+
+	reasons := []string{}
+	for _, name := range sortedKeys(expected.headers) {
+		values, ok := r.Header[http.CanonicalHeaderKey(name)]
+		switch {
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("header %s is required", name))
+		case !contains(values, expected.headers[name]):
+			reasons = append(reasons, fmt.Sprintf("header %s has the wrong value", name))
+		}
+	}
+	query := r.URL.Query()
+	for _, name := range sortedKeys(expected.queryParams) {
+		values, ok := query[name]
+		switch {
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("query param %s is required", name))
+		case !contains(values, expected.queryParams[name]):
+			reasons = append(reasons, fmt.Sprintf("query param %s has the wrong value", name))
+		}
+	}
+	if len(reasons) > 0 {
+		return http.StatusForbidden, reasons
+	}
+	return http.StatusOK, nil
+}
+
+//mutating calls need a valid token, other calls get one with X-CSRF-Token: Fetch
+func checkCSRF(w http.ResponseWriter, r *http.Request) (int, []string) {
+
+	if !mutating(r.Method) {
+		if r.Header.Get(csrfHeader) == csrfFetch {
+			if err := issueCSRFToken(w, r); err != nil {
+				return http.StatusInternalServerError, []string{err.Error()}
+			}
+		}
+		return http.StatusOK, nil
+	}
+
+	if reason := checkCSRFToken(r); reason != "" {
+		w.Header().Set(csrfHeader, csrfRequired)
+		return http.StatusForbidden, []string{reason}
+	}
+	return http.StatusOK, nil
+}
+
+func contains(values []string, expected string) bool {
+	for _, value := range values {
+		if equal(value, expected) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//RequireAuth - refuses calls without the expected credentials with 401, calls missing headers or query params with 403
+//with csrf mutating calls without a valid csrf token are refused with 403, other calls may fetch a token
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, expected, err := currentAuth()
+
+		if err != nil {
+			log.Printf("%s %s: %s", r.Method, r.URL.Path, err)
+			utils.ReturnJSONWithStatus(authError{"server_error", []string{err.Error()}}, http.StatusInternalServerError, w)
+			return
+		}
+		if expected == nil {
+			next(w, r)
+			return
+		}
+
+		status, reasons := expected.check(r)
+		if status == http.StatusOK && expected.csrf {
+			status, reasons = checkCSRF(w, r)
+		}
+		if status == http.StatusOK {
+			next(w, r)
+			return
+		}
+
+		log.Printf("%s %s: refused with %d: %s", r.Method, r.URL.Path, status, strings.Join(reasons, ", "))
+		errorCode := "forbidden"
+		switch status {
+		case http.StatusInternalServerError:
+			errorCode = "server_error"
+		case http.StatusUnauthorized:
+			errorCode = "unauthorized"
+			if expected.basicUsername != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="mock"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mock"`)
+			}
+		}
+		utils.ReturnJSONWithStatus(authError{errorCode, reasons}, status, w)
+	}
+}
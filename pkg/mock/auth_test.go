@@ -0,0 +1,151 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+//the auth registered for the test, the previous one is restored by the returned function
+func useTestAuth(t *testing.T, auth connector.AuthConfig) func() {
+
+	for _, env := range []string{AuthModeEnv, CSRFEnv, ClientIDEnv, connector.PackageConfigEnv} {
+		os.Unsetenv(env)
+	}
+	os.Setenv(ClientSecretEnv, "secret")
+	loadAuth()
+
+	mockAuth.mu.RLock()
+	previous, expected, err := mockAuth.auth, mockAuth.expected, mockAuth.err
+	mockAuth.mu.RUnlock()
+
+	if err := UseAuth(auth); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		mockAuth.mu.Lock()
+		defer mockAuth.mu.Unlock()
+		mockAuth.auth, mockAuth.expected, mockAuth.err = previous, expected, err
+	}
+}
+
+func callProtected(r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(rec, r)
+	return rec
+}
+
+func TestRequireAuth(t *testing.T) {
+
+	//the default instance auth of a package registered with the connect request
+	defer useTestAuth(t, connector.AuthConfig{
+		Basic:       &connector.BasicCredential{Username: "orders", Password: connector.Secret{Value: "orders-password"}},
+		Headers:     map[string]connector.Secret{"header1": {Value: "value1"}},
+		QueryParams: map[string]connector.Secret{"query1": {Value: "value1"}},
+	})()
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		header   string
+		query    string
+		status   int
+		reasons  []string
+	}{
+		{"valid", "orders", "orders-password", "value1", "?query1=value1", http.StatusOK, nil},
+		{"no basic auth", "", "", "value1", "?query1=value1", http.StatusUnauthorized, []string{"basic auth is required"}},
+		{"wrong password", "orders", "demo", "value1", "?query1=value1", http.StatusUnauthorized, []string{`wrong username or password for user "orders"`}},
+		{"wrong username", "demo", "orders-password", "value1", "?query1=value1", http.StatusUnauthorized, []string{`wrong username or password for user "demo"`}},
+		{"missing header and query param", "orders", "orders-password", "", "", http.StatusForbidden, []string{"header header1 is required", "query param query1 is required"}},
+		{"wrong header", "orders", "orders-password", "value2", "?query1=value1", http.StatusForbidden, []string{"header header1 has the wrong value"}},
+		{"wrong query param", "orders", "orders-password", "value1", "?query1=value2", http.StatusForbidden, []string{"query param query1 has the wrong value"}},
+		//the credentials are checked first
+		{"no basic auth and missing header", "", "", "", "", http.StatusUnauthorized, []string{"basic auth is required"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/orders"+test.query, nil)
+			if test.username != "" {
+				r.SetBasicAuth(test.username, test.password)
+			}
+			if test.header != "" {
+				r.Header.Set("header1", test.header)
+			}
+
+			rec := callProtected(r)
+			if rec.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, test.status, rec.Body.String())
+			}
+			if test.status == http.StatusOK {
+				return
+			}
+
+			var refused authError
+			if err := json.Unmarshal(rec.Body.Bytes(), &refused); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(refused.Reasons, test.reasons) {
+				t.Errorf("reasons = %q, want %q", refused.Reasons, test.reasons)
+			}
+
+			authenticate := rec.Header().Get("WWW-Authenticate")
+			switch test.status {
+			case http.StatusUnauthorized:
+				if refused.Error != "unauthorized" || authenticate != `Basic realm="mock"` {
+					t.Errorf("error = %s, WWW-Authenticate = %q, want unauthorized and the basic realm", refused.Error, authenticate)
+				}
+			case http.StatusForbidden:
+				if refused.Error != "forbidden" || authenticate != "" {
+					t.Errorf("error = %s, WWW-Authenticate = %q, want forbidden without a challenge", refused.Error, authenticate)
+				}
+			}
+		})
+	}
+}
+
+func TestUseAuthReplacesAuth(t *testing.T) {
+
+	defer useTestAuth(t, connector.AuthConfig{
+		Basic: &connector.BasicCredential{Username: "first", Password: connector.Secret{Value: "first-password"}},
+	})()
+
+	if err := UseAuth(connector.AuthConfig{
+		Basic: &connector.BasicCredential{Username: "second", Password: connector.Secret{Value: "second-password"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if auth := Auth(); auth == nil || auth.Basic == nil || auth.Basic.Username != "second" {
+		t.Errorf("Auth() = %+v, want the auth registered last", auth)
+	}
+
+	r := httptest.NewRequest("GET", "/orders", nil)
+	r.SetBasicAuth("first", "first-password")
+	if rec := callProtected(r); rec.Code != http.StatusUnauthorized {
+		t.Errorf("the replaced credentials got %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	r = httptest.NewRequest("GET", "/orders", nil)
+	r.SetBasicAuth("second", "second-password")
+	if rec := callProtected(r); rec.Code != http.StatusOK {
+		t.Errorf("the registered credentials got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	//an auth with a secret that cannot be resolved keeps the current one
+	if err := UseAuth(connector.AuthConfig{
+		Basic: &connector.BasicCredential{Username: "third", Password: connector.Secret{Env: "TEST_MOCK_UNSET_PASSWORD"}},
+	}); err == nil {
+		t.Error("UseAuth accepted a password of an unset env")
+	}
+	if auth := Auth(); auth == nil || auth.Basic == nil || auth.Basic.Username != "second" {
+		t.Errorf("Auth() = %+v, want the auth registered before", auth)
+	}
+}
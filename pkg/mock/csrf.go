@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+//CSRFEnv - true requires an X-CSRF-Token fetched from CSRFPath for mutating calls of the mock api
+const CSRFEnv string = "APP_CONN_MOCK_CSRF"
+
+//CSRFLifetimeEnv - lifetime of the csrf tokens, e.g. 10m
+const CSRFLifetimeEnv string = "APP_CONN_MOCK_CSRF_LIFETIME"
+
+//CSRFPath - path of the csrf token endpoint of the mock api
+const CSRFPath string = "/csrf"
+
+const csrfHeader string = "X-CSRF-Token"
+const csrfFetch string = "Fetch"
+const csrfRequired string = "Required"
+
+//the csrf token is bound to the session of the cookie it was fetched with
+const sessionCookie string = "MOCK_SESSIONID"
+
+const defaultCSRFLifetime time.Duration = 30 * time.Minute
+
+type csrfToken struct {
+	session string
+	expiry  time.Time
+}
+
+//csrf tokens issued by the token endpoint
+var csrfTokens = struct {
+	sync.Mutex
+	tokens map[string]csrfToken
+}{tokens: map[string]csrfToken{}}
+
+func csrfEnabled() bool {
+	return os.Getenv(CSRFEnv) == "true"
+}
+
+func csrfLifetime() time.Duration {
+	if value := os.Getenv(CSRFLifetimeEnv); value != "" {
+		lifetime, err := time.ParseDuration(value)
+		if err == nil && lifetime > 0 {
+			return lifetime
+		}
+		log.Printf("Ignoring %s=%q, it is not a positive duration", CSRFLifetimeEnv, value)
+	}
+	return defaultCSRFLifetime
+}
+
+//calls changing orders need a csrf token
+func mutating(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
+
+func randomHex(size int) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+//FetchCSRFToken - returns a csrf token in the X-CSRF-Token header and the session it is bound to as cookie
+func FetchCSRFToken(w http.ResponseWriter, r *http.Request) {
+	log.Println("FetchCSRFToken")
+
+	//RequireAuth has already issued one for X-CSRF-Token: Fetch
+	if w.Header().Get(csrfHeader) != "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := issueCSRFToken(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+//sets the token header and the session cookie, the session of the request is kept
+func issueCSRFToken(w http.ResponseWriter, r *http.Request) error {
+
+	session := ""
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		session = cookie.Value
+	}
+
+	var err error
+	if session == "" {
+		if session, err = randomHex(16); err != nil {
+			return err
+		}
+	}
+	token, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+
+	csrfTokens.Lock()
+	now := time.Now()
+	//expired tokens are dropped so the map does not grow
+	for issued, csrf := range csrfTokens.tokens {
+		if now.After(csrf.expiry) {
+			delete(csrfTokens.tokens, issued)
+		}
+	}
+	csrfTokens.tokens[token] = csrfToken{session: session, expiry: now.Add(csrfLifetime())}
+	csrfTokens.Unlock()
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: session, Path: "/", HttpOnly: true})
+	w.Header().Set(csrfHeader, token)
+	return nil
+}
+
+//the reason the csrf token of the call is refused, empty if it is valid
+func checkCSRFToken(r *http.Request) string {
+
+	token := r.Header.Get(csrfHeader)
+	if token == "" || token == csrfFetch {
+		return "an " + csrfHeader + " header fetched from " + CSRFPath + " is required"
+	}
+
+	csrfTokens.Lock()
+	csrf, ok := csrfTokens.tokens[token]
+	csrfTokens.Unlock()
+
+	switch {
+	case !ok:
+		return "the csrf token is unknown"
+	case time.Now().After(csrf.expiry):
+		return "the csrf token has expired"
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || !equal(cookie.Value, csrf.session) {
+		return "the csrf token does not belong to the session of the " + sessionCookie + " cookie"
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+//the csrf token endpoint and a protected orders route
+func newCSRFTestServer() *httptest.Server {
+	router := mux.NewRouter()
+	router.HandleFunc(CSRFPath, RequireAuth(FetchCSRFToken)).Methods("GET", "HEAD")
+	router.HandleFunc("/orders", RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).Methods("GET", "POST")
+	return httptest.NewServer(router)
+}
+
+//a client keeping the session cookie like a browser session
+func newSessionClient(t *testing.T) *http.Client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+func callCSRF(t *testing.T, client *http.Client, method string, requestURL string, token string) *http.Response {
+	req, err := http.NewRequest(method, requestURL, strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("header1", "value1")
+	if token != "" {
+		req.Header.Set(csrfHeader, token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+//the token fetched by the client, its session cookie is kept by the client
+func fetchCSRFToken(t *testing.T, client *http.Client, requestURL string) string {
+	resp := callCSRF(t, client, "GET", requestURL, csrfFetch)
+	resp.Body.Close()
+
+	token := resp.Header.Get(csrfHeader)
+	if resp.StatusCode != http.StatusOK || token == "" {
+		t.Fatalf("fetching a csrf token = %d with token %q, want 200 and a token", resp.StatusCode, token)
+	}
+	return token
+}
+
+//checks the refusal and returns its reason
+func refusedCSRF(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden || resp.Header.Get(csrfHeader) != csrfRequired {
+		t.Errorf("status = %d with %s %q, want 403 and %s", resp.StatusCode, csrfHeader, resp.Header.Get(csrfHeader), csrfRequired)
+	}
+
+	var refused authError
+	if err := json.NewDecoder(resp.Body).Decode(&refused); err != nil {
+		t.Fatal(err)
+	}
+	if len(refused.Reasons) != 1 {
+		t.Fatalf("reasons = %q, want one", refused.Reasons)
+	}
+	return refused.Reasons[0]
+}
+
+func TestCSRF(t *testing.T) {
+
+	defer useTestAuth(t, connector.AuthConfig{
+		Headers: map[string]connector.Secret{"header1": {Value: "value1"}},
+		CSRF:    &connector.CSRFConfig{TokenEndpointURL: CSRFPath},
+	})()
+
+	server := newCSRFTestServer()
+	defer server.Close()
+
+	session := newSessionClient(t)
+	token := fetchCSRFToken(t, session, server.URL+CSRFPath)
+
+	serverURL := server.URL
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := session.Jar.Cookies(parsed)
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie {
+		t.Fatalf("cookies = %v, want the session cookie %s", cookies, sessionCookie)
+	}
+
+	t.Run("post with the token", func(t *testing.T) {
+		resp := callCSRF(t, session, "POST", serverURL+"/orders", token)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status = %d, want 200", resp.StatusCode)
+		}
+	})
+
+	t.Run("get without a token", func(t *testing.T) {
+		resp := callCSRF(t, session, "GET", serverURL+"/orders", "")
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status = %d, want 200", resp.StatusCode)
+		}
+	})
+
+	t.Run("post without a token", func(t *testing.T) {
+		reason := refusedCSRF(t, callCSRF(t, session, "POST", serverURL+"/orders", ""))
+		if reason != "an X-CSRF-Token header fetched from /csrf is required" {
+			t.Errorf("reason = %q", reason)
+		}
+	})
+
+	t.Run("token of another session", func(t *testing.T) {
+		other := newSessionClient(t)
+		fetchCSRFToken(t, other, serverURL+CSRFPath)
+
+		reason := refusedCSRF(t, callCSRF(t, other, "POST", serverURL+"/orders", token))
+		if reason != "the csrf token does not belong to the session of the MOCK_SESSIONID cookie" {
+			t.Errorf("reason = %q", reason)
+		}
+	})
+
+	t.Run("token without a session", func(t *testing.T) {
+		reason := refusedCSRF(t, callCSRF(t, http.DefaultClient, "POST", serverURL+"/orders", token))
+		if reason != "the csrf token does not belong to the session of the MOCK_SESSIONID cookie" {
+			t.Errorf("reason = %q", reason)
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		reason := refusedCSRF(t, callCSRF(t, session, "POST", serverURL+"/orders", "0123456789abcdef"))
+		if reason != "the csrf token is unknown" {
+			t.Errorf("reason = %q", reason)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := fetchCSRFToken(t, session, serverURL+"/orders")
+		csrfTokens.Lock()
+		csrf := csrfTokens.tokens[expired]
+		csrf.expiry = time.Now().Add(-time.Second)
+		csrfTokens.tokens[expired] = csrf
+		csrfTokens.Unlock()
+
+		reason := refusedCSRF(t, callCSRF(t, session, "POST", serverURL+"/orders", expired))
+		if reason != "the csrf token has expired" {
+			t.Errorf("reason = %q", reason)
+		}
+	})
+
+	//the fetch needs the headers of the other calls
+	t.Run("fetch without the header", func(t *testing.T) {
+		req := httptest.NewRequest("GET", CSRFPath, nil)
+		req.Header.Set(csrfHeader, csrfFetch)
+		rec := callProtected(req)
+		if rec.Code != http.StatusForbidden || rec.Header().Get(csrfHeader) != "" {
+			t.Errorf("status = %d with token %q, want 403 without a token", rec.Code, rec.Header().Get(csrfHeader))
+		}
+	})
+}
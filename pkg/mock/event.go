@@ -1,12 +1,10 @@
 package mock
 
 import (
-	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/jcawley/kyma-app-connector/pkg/connector"
 	"github.com/jcawley/kyma-app-connector/pkg/utils"
@@ -16,13 +14,6 @@ import (
 func SendOrderCreatedEvent(w http.ResponseWriter, r *http.Request) {
 	log.Println("SendOrderCreatedEvent")
 
-	client := connector.GetHTTPTLSClient()
-
-	if client == nil {
-		utils.ReturnError("No TLS Connection established", w)
-		return
-	}
-
 	defer r.Body.Close()
 	orderCode, err := ioutil.ReadAll(r.Body)
 
@@ -31,27 +22,15 @@ func SendOrderCreatedEvent(w http.ResponseWriter, r *http.Request) {
 		orderCode = []byte("12345")
 	}
 
-	eventMessage := map[string]interface{}{
-		"event-type":         "orderCreated",
-		"event-type-version": "v1",
-		"event-id":           "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
-		"event-time":         time.Now().Format(time.RFC3339),
-		"data": map[string]string{
-			"orderCode": string(orderCode),
-		},
-	}
-
-	eventBytes, _ := json.Marshal(eventMessage)
-
-	eventURL := connector.GetEventURL()
+	data, _ := json.Marshal(map[string]string{
+		"orderCode": string(orderCode),
+	})
 
-	resp, err := client.Post(eventURL, "application/json", bytes.NewBuffer(eventBytes))
+	respBody, err := connector.SendEvent(connector.ConnectionName(r), "orderCreated", "v1", data)
 
 	if err != nil {
 		utils.ReturnError(err.Error(), w)
 	} else {
-		defer resp.Body.Close()
-		respBody, _ := ioutil.ReadAll(resp.Body)
 		AddOrderFromEvent(string(orderCode))
 		utils.ReturnSuccess(string(respBody), w)
 	}
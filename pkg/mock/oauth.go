@@ -0,0 +1,113 @@
+package mock
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jcawley/kyma-app-connector/pkg/utils"
+)
+
+//ClientIDEnv - client id of the default auth of the mock api
+const ClientIDEnv string = "APP_CONN_MOCK_CLIENT_ID"
+
+//ClientSecretEnv - client secret of the default auth of the mock api
+const ClientSecretEnv string = "APP_CONN_MOCK_CLIENT_SECRET"
+
+//TokenPath - path of the oauth client credentials token endpoint of the mock api
+const TokenPath string = "/oauth/token"
+
+//lifetime of the issued access tokens
+const tokenLifetime time.Duration = time.Hour
+
+//access tokens issued by the token endpoint and their expiry
+var tokens = struct {
+	sync.Mutex
+	expiry map[string]time.Time
+}{expiry: map[string]time.Time{}}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+//the oauth client of the auth of the mock api, empty if it is not protected with oauth
+func oauthClient() (string, string) {
+	_, expected, _ := currentAuth()
+	if expected == nil {
+		return "", ""
+	}
+	return expected.clientID, expected.clientSecret
+}
+
+//Token - the client credentials token endpoint, the client authenticates with basic auth or the client_id and client_secret form fields
+func Token(w http.ResponseWriter, r *http.Request) {
+	log.Println("Token")
+
+	if err := r.ParseForm(); err != nil {
+		utils.ReturnJSONWithStatus(oauthError{"invalid_request", err.Error()}, http.StatusBadRequest, w)
+		return
+	}
+
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		utils.ReturnJSONWithStatus(oauthError{"unsupported_grant_type", "only client_credentials is supported"}, http.StatusBadRequest, w)
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	expectedID, expectedSecret := oauthClient()
+	if expectedID == "" || !equal(clientID, expectedID) || !equal(clientSecret, expectedSecret) {
+		log.Printf("Token: invalid client %q", clientID)
+		w.Header().Set("WWW-Authenticate", `Basic realm="mock"`)
+		utils.ReturnJSONWithStatus(oauthError{"invalid_client", "unknown client or wrong secret"}, http.StatusUnauthorized, w)
+		return
+	}
+
+	token, err := issueToken()
+	if err != nil {
+		utils.ReturnJSONWithStatus(oauthError{"server_error", err.Error()}, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	utils.ReturnJSON(tokenResponse{AccessToken: token, TokenType: "bearer", ExpiresIn: int(tokenLifetime.Seconds())}, w)
+}
+
+func issueToken() (string, error) {
+	token, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	tokens.Lock()
+	defer tokens.Unlock()
+
+	now := time.Now()
+	//expired tokens are dropped so the map does not grow
+	for issued, expiry := range tokens.expiry {
+		if now.After(expiry) {
+			delete(tokens.expiry, issued)
+		}
+	}
+	tokens.expiry[token] = now.Add(tokenLifetime)
+	return token, nil
+}
+
+func validToken(token string) bool {
+	tokens.Lock()
+	defer tokens.Unlock()
+
+	expiry, ok := tokens.expiry[token]
+	return ok && time.Now().Before(expiry)
+}
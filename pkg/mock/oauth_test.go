@@ -0,0 +1,172 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+const testClientID string = "orders-client"
+const testClientSecret string = "orders-secret"
+
+func useTestOAuth(t *testing.T) func() {
+	return useTestAuth(t, connector.AuthConfig{
+		OAuth: &connector.OAuthCredential{ClientID: testClientID, ClientSecret: connector.Secret{Value: testClientSecret}, URL: TokenPath},
+	})
+}
+
+//posts the form to the token endpoint, with basic auth if the username is set
+func requestToken(form url.Values, username string, password string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", TokenPath, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if username != "" {
+		r.SetBasicAuth(username, password)
+	}
+
+	rec := httptest.NewRecorder()
+	Token(rec, r)
+	return rec
+}
+
+func TestToken(t *testing.T) {
+
+	defer useTestOAuth(t)()
+
+	clientCredentials := url.Values{"grant_type": {"client_credentials"}}
+	withClient := func(id string, secret string) url.Values {
+		return url.Values{"grant_type": {"client_credentials"}, "client_id": {id}, "client_secret": {secret}}
+	}
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		username string
+		password string
+		status   int
+		error    string
+	}{
+		{"basic auth", clientCredentials, testClientID, testClientSecret, http.StatusOK, ""},
+		{"form fields", withClient(testClientID, testClientSecret), "", "", http.StatusOK, ""},
+		{"wrong secret", clientCredentials, testClientID, "wrong", http.StatusUnauthorized, "invalid_client"},
+		{"wrong secret in the form", withClient(testClientID, "wrong"), "", "", http.StatusUnauthorized, "invalid_client"},
+		{"unknown client", withClient("other-client", testClientSecret), "", "", http.StatusUnauthorized, "invalid_client"},
+		{"no client", clientCredentials, "", "", http.StatusUnauthorized, "invalid_client"},
+		{"password grant", url.Values{"grant_type": {"password"}, "username": {"orders"}, "password": {"orders"}}, testClientID, testClientSecret, http.StatusBadRequest, "unsupported_grant_type"},
+		{"no grant type", url.Values{}, testClientID, testClientSecret, http.StatusBadRequest, "unsupported_grant_type"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := requestToken(test.form, test.username, test.password)
+			if rec.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, test.status, rec.Body.String())
+			}
+
+			if test.status != http.StatusOK {
+				var refused oauthError
+				if err := json.Unmarshal(rec.Body.Bytes(), &refused); err != nil {
+					t.Fatal(err)
+				}
+				if refused.Error != test.error {
+					t.Errorf("error = %q, want %q", refused.Error, test.error)
+				}
+				if test.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+					t.Error("the invalid client got no WWW-Authenticate challenge")
+				}
+				return
+			}
+
+			var token tokenResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &token); err != nil {
+				t.Fatal(err)
+			}
+			if token.AccessToken == "" || token.TokenType != "bearer" || token.ExpiresIn != int(tokenLifetime.Seconds()) {
+				t.Errorf("token = %+v, want a bearer token valid for %s", token, tokenLifetime)
+			}
+			if rec.Header().Get("Cache-Control") != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", rec.Header().Get("Cache-Control"))
+			}
+			if !validToken(token.AccessToken) {
+				t.Error("the issued token is not valid")
+			}
+		})
+	}
+}
+
+func TestRequireAuthBearerToken(t *testing.T) {
+
+	defer useTestOAuth(t)()
+
+	issue := func() string {
+		rec := requestToken(url.Values{"grant_type": {"client_credentials"}}, testClientID, testClientSecret)
+		var token tokenResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &token); err != nil || token.AccessToken == "" {
+			t.Fatalf("no token issued: %s", rec.Body.String())
+		}
+		return token.AccessToken
+	}
+
+	valid := issue()
+	expired := issue()
+	tokens.Lock()
+	tokens.expiry[expired] = time.Now().Add(-time.Second)
+	tokens.Unlock()
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		reasons       []string
+	}{
+		{"valid token", "Bearer " + valid, http.StatusOK, nil},
+		{"lower case scheme", "bearer " + valid, http.StatusOK, nil},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, []string{"the bearer token is invalid or expired"}},
+		{"unknown token", "Bearer 0123456789abcdef", http.StatusUnauthorized, []string{"the bearer token is invalid or expired"}},
+		{"no token", "", http.StatusUnauthorized, []string{"a bearer token of " + TokenPath + " is required"}},
+		{"client credentials instead of a token", "Basic b3JkZXJzLWNsaWVudDpvcmRlcnMtc2VjcmV0", http.StatusUnauthorized, []string{"a bearer token of " + TokenPath + " is required"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/orders", nil)
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+
+			rec := callProtected(r)
+			if rec.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, test.status, rec.Body.String())
+			}
+			if test.status == http.StatusOK {
+				return
+			}
+
+			var refused authError
+			if err := json.Unmarshal(rec.Body.Bytes(), &refused); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(refused.Reasons, test.reasons) {
+				t.Errorf("reasons = %q, want %q", refused.Reasons, test.reasons)
+			}
+			if authenticate := rec.Header().Get("WWW-Authenticate"); authenticate != `Bearer realm="mock"` {
+				t.Errorf("WWW-Authenticate = %q, want the bearer realm", authenticate)
+			}
+		})
+	}
+
+	//expired tokens are dropped when the next one is issued
+	issue()
+	tokens.Lock()
+	_, kept := tokens.expiry[expired]
+	tokens.Unlock()
+	if kept {
+		t.Error("the expired token was kept")
+	}
+}
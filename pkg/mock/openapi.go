@@ -0,0 +1,374 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+)
+
+const apiTitle string = "Order API"
+const apiVersion string = "0.0.1"
+
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+//builds the document, named struct types are added to the schemas of the components
+type generator struct {
+	schemas map[string]interface{}
+}
+
+//OpenAPI - generates the open api document of the mock routes registered with the router
+func OpenAPI(router *mux.Router) ([]byte, error) {
+
+	g := &generator{schemas: map[string]interface{}{}}
+	paths := map[string]interface{}{}
+
+	err := router.Walk(func(muxRoute *mux.Route, r *mux.Router, ancestors []*mux.Route) error {
+		template, err := muxRoute.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := muxRoute.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			route := findRoute(template, method)
+			if route == nil {
+				continue
+			}
+
+			//regular expressions of path variables are not part of the open api path
+			path := pathVariable.ReplaceAllString(template, "{$1}")
+			item, ok := paths[path].(map[string]interface{})
+			if !ok {
+				item = map[string]interface{}{}
+				paths[path] = item
+			}
+			item[strings.ToLower(method)] = g.operation(*route, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   apiTitle,
+			"version": apiVersion,
+		},
+		"paths": paths,
+	}
+	components := map[string]interface{}{}
+	if len(g.schemas) > 0 {
+		components["schemas"] = g.schemas
+	}
+	if auth := Auth(); auth != nil {
+		schemes := securitySchemes(*auth)
+		requirement := map[string]interface{}{}
+		for name := range schemes {
+			requirement[name] = []string{}
+		}
+		components["securitySchemes"] = schemes
+		document["security"] = []interface{}{requirement}
+	}
+	if len(components) > 0 {
+		document["components"] = components
+	}
+
+	return json.MarshalIndent(document, "", "  ")
+}
+
+//the schemes of the auth of the mock api, a call has to satisfy all of them
+//required headers and query params are api keys, the token url may be relative to the server of the api
+func securitySchemes(auth connector.AuthConfig) map[string]interface{} {
+
+	schemes := map[string]interface{}{}
+	if auth.Basic != nil {
+		schemes["basic"] = map[string]interface{}{"type": "http", "scheme": "basic"}
+	}
+	if auth.OAuth != nil {
+		schemes["oauth"] = map[string]interface{}{
+			"type": "oauth2",
+			"flows": map[string]interface{}{
+				"clientCredentials": map[string]interface{}{
+					"tokenUrl": auth.OAuth.URL,
+					"scopes":   map[string]interface{}{},
+				},
+			},
+		}
+	}
+	for name := range auth.Headers {
+		schemes["header-"+name] = map[string]interface{}{"type": "apiKey", "in": "header", "name": name}
+	}
+	for name := range auth.QueryParams {
+		schemes["query-"+name] = map[string]interface{}{"type": "apiKey", "in": "query", "name": name}
+	}
+	return schemes
+}
+
+//the route of the mock api registered under the template and method
+func findRoute(template string, method string) *Route {
+	for i := range Routes {
+		if Routes[i].Path == template && Routes[i].Method == method {
+			return &Routes[i]
+		}
+	}
+	return nil
+}
+
+func (g *generator) operation(route Route, path string) map[string]interface{} {
+
+	operation := map[string]interface{}{
+		"description": route.Description,
+		"tags":        []string{"orders"},
+	}
+
+	parameters := []interface{}{}
+	for _, match := range pathVariable.FindAllStringSubmatch(path, -1) {
+		parameter := map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		}
+		if description := route.Parameters[match[1]]; description != "" {
+			parameter["description"] = description
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	//the csrf token is sent as header of mutating calls
+	if auth := Auth(); auth != nil && auth.CSRF != nil && mutating(route.Method) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        csrfHeader,
+			"in":          "header",
+			"required":    true,
+			"description": "csrf token fetched from " + auth.CSRF.TokenEndpointURL + " with " + csrfHeader + ": " + csrfFetch,
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if route.Request != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  g.content(route.Request),
+		}
+	}
+
+	responses := map[string]interface{}{}
+	for _, response := range route.Responses {
+		responseObject := map[string]interface{}{"description": response.Description}
+		if response.Body != nil {
+			responseObject["content"] = g.content(response.Body)
+		}
+		responses[strconv.Itoa(response.Status)] = responseObject
+	}
+	if auth := Auth(); auth != nil {
+		if auth.Basic != nil || auth.OAuth != nil {
+			responses[strconv.Itoa(http.StatusUnauthorized)] = map[string]interface{}{"description": "Missing or invalid credentials."}
+		}
+		if len(auth.Headers) > 0 || len(auth.QueryParams) > 0 {
+			responses[strconv.Itoa(http.StatusForbidden)] = map[string]interface{}{"description": "Missing or wrong headers or query params."}
+		}
+		if auth.CSRF != nil && mutating(route.Method) {
+			responses[strconv.Itoa(http.StatusForbidden)] = map[string]interface{}{"description": "Missing, invalid or expired csrf token, or missing or wrong headers or query params."}
+		}
+	}
+	operation["responses"] = responses
+
+	return operation
+}
+
+//the bodies of the mock api are json
+func (g *generator) content(body interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": g.schema(reflect.TypeOf(body)),
+		},
+	}
+}
+
+//the schema of a go type as encoded by encoding/json, named structs are referenced
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			//registered first so recursive types end up as references
+			g.schemas[name] = nil
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		//[]byte is encoded as base64
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+//the properties are named by the json tags, fields without omitempty are required
+//the example and description tags are added to the property
+func (g *generator) structSchema(t reflect.Type) map[string]interface{} {
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	g.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *generator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma != -1 {
+			name, options = tag[:comma], tag[comma+1:]
+		}
+
+		//fields of embedded structs without a name are promoted
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties, required)
+				continue
+			}
+		}
+
+		//unexported fields are not encoded
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if example, ok := field.Tag.Lookup("example"); ok {
+			property["example"] = exampleValue(field.Type, example)
+		}
+		//siblings of $ref are ignored, the reference is wrapped to keep them
+		if ref, ok := property["$ref"]; ok && len(property) > 1 {
+			delete(property, "$ref")
+			property["allOf"] = []interface{}{map[string]interface{}{"$ref": ref}}
+		}
+		properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+//the example tag converted to the type of the field, numbers and booleans that do not parse are kept as text
+func exampleValue(t reflect.Type, example string) interface{} {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if value, err := strconv.ParseBool(example); err == nil {
+			return value
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value, err := strconv.ParseInt(example, 10, 64); err == nil {
+			return value
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value, err := strconv.ParseUint(example, 10, 64); err == nil {
+			return value
+		}
+	case reflect.Float32, reflect.Float64:
+		if value, err := strconv.ParseFloat(example, 64); err == nil {
+			return value
+		}
+	}
+	return example
+}
+
+//the type name starting with an upper case letter, e.g. Order for order
+func schemaName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+//ServeOpenAPI - returns a handler serving the open api document of the mock routes registered with the router
+func ServeOpenAPI(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, err := OpenAPI(router)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(document)
+	}
+}
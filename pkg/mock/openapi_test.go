@@ -0,0 +1,60 @@
+package mock
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jcawley/kyma-app-connector/pkg/connector"
+	"github.com/jcawley/kyma-app-connector/pkg/validate"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+const goldenOpenAPI string = "openapi.golden.json"
+
+//the document of the default auth, the routes of the app besides the mock api are not part of it
+func TestOpenAPI(t *testing.T) {
+
+	for _, env := range []string{AuthModeEnv, CSRFEnv, ClientIDEnv, connector.PackageConfigEnv} {
+		os.Unsetenv(env)
+	}
+	os.Setenv(ClientSecretEnv, "secret")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/sendOrderCreatedEvent", SendOrderCreatedEvent)
+	router.HandleFunc("/openapi.json", ServeOpenAPI(router)).Methods("GET")
+	RegisterRoutes(router)
+
+	document, err := OpenAPI(router)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document = append(document, '\n')
+
+	if problems := validate.OpenAPI(document); len(problems) != 0 {
+		t.Errorf("the generated document is invalid: %v", problems)
+	}
+	if bytes.Contains(document, []byte("secret")) {
+		t.Error("the generated document contains the client secret")
+	}
+
+	golden := filepath.Join("testdata", goldenOpenAPI)
+	if *update {
+		if err := ioutil.WriteFile(golden, document, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(document, expected) {
+		t.Errorf("the generated document differs from %s, run go test -update if the change is intended:\n%s", golden, document)
+	}
+}
@@ -13,9 +13,74 @@ import (
 )
 
 type order struct {
-	OrderCode   string  `json:"orderCode"`
-	Description string  `json:"description"`
-	Total       float64 `json:"total"`
+	OrderCode   string  `json:"orderCode" example:"11854638GU110615ELIN54ZQ" description:"code identifying the order"`
+	Description string  `json:"description" example:"some order description"`
+	Total       float64 `json:"total" example:"1234.56" description:"total amount of the order"`
+}
+
+//Route - a route of the mock api, the generated open api document describes it
+type Route struct {
+	Method      string
+	Path        string
+	Handler     http.HandlerFunc
+	Description string
+	//descriptions of the path parameters by name
+	Parameters map[string]string
+	//a value of the type of the request body, nil if there is none
+	Request   interface{}
+	Responses []Response
+}
+
+//Response - a response of a route, body is a value of the type of the response body or nil
+type Response struct {
+	Status      int
+	Description string
+	Body        interface{}
+}
+
+//Routes - the routes of the mock api
+var Routes = []Route{
+	{
+		Method:      "GET",
+		Path:        "/orders",
+		Handler:     GetOrders,
+		Description: "Retrieve all orders.",
+		Responses: []Response{
+			{http.StatusOK, "Orders retrieved succesfully.", []order{}},
+			{http.StatusInternalServerError, "Internal server error.", nil},
+		},
+	},
+	{
+		Method:      "GET",
+		Path:        "/orders/{id}",
+		Handler:     GetOrder,
+		Description: "Retrieve a single order.",
+		Parameters:  map[string]string{"id": "The orderCode to retrieve"},
+		Responses: []Response{
+			{http.StatusOK, "Order retrieved succesfully, an empty object if no order has the orderCode.", order{}},
+			{http.StatusInternalServerError, "Internal server error.", nil},
+		},
+	},
+	{
+		Method:      "POST",
+		Path:        "/orders",
+		Handler:     PostOrders,
+		Description: "Creates a new order.",
+		Request:     order{},
+		Responses: []Response{
+			{http.StatusOK, "Order created succesfully, returns all orders.", []order{}},
+			{http.StatusInternalServerError, "Internal server error.", nil},
+		},
+	},
+}
+
+//RegisterRoutes - adds the routes of the mock api and its token endpoints to the router, the routes require the credentials of Auth
+func RegisterRoutes(router *mux.Router) {
+	router.HandleFunc(TokenPath, Token).Methods("POST")
+	router.HandleFunc(CSRFPath, RequireAuth(FetchCSRFToken)).Methods("GET", "HEAD")
+	for _, route := range Routes {
+		router.HandleFunc(route.Path, RequireAuth(route.Handler)).Methods(route.Method)
+	}
 }
 
 var orders []order
@@ -112,16 +177,19 @@ func AddOrderFromEvent(orderCode string) {
 	orders = append(orders, order)
 }
 
-//print the user/pass and headers in the logs
+//print the user and headers in the logs, the credentials have been checked by RequireAuth
 func printReqData(r *http.Request) {
 	log.Println("Printing request data...")
-	user, pass, _ := r.BasicAuth()
-	log.Printf("user: %s pass: %s", user, pass)
+	user, _, _ := r.BasicAuth()
+	log.Printf("user: %s", user)
 
 	// print headers...
 	for name, headers := range r.Header {
 		name = strings.ToLower(name)
 		for _, h := range headers {
+			if name == "authorization" {
+				h = "(redacted)"
+			}
 			log.Printf("%v: %v", name, h)
 		}
 	}
@@ -0,0 +1,251 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	envelopeCipher string = "AES-256-GCM"
+	kdfPBKDF2      string = "PBKDF2-HMAC-SHA256"
+	kdfNone        string = "none"
+	//OWASP recommendation for PBKDF2-HMAC-SHA256
+	pbkdf2Iterations int = 600000
+	keyLength        int = 32
+)
+
+//envelope - an encrypted credential, the connection and credential name are authenticated as additional data
+type envelope struct {
+	Cipher     string `json:"cipher"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+//ErrNotEncrypted - the stored credential is no envelope and unencrypted credentials are not migrated
+var ErrNotEncrypted = errors.New("credential is not encrypted")
+
+//EncryptedStore - encrypts every credential with AES-256-GCM before it is passed to the underlying store
+//the key is either given directly or derived from a passphrase with PBKDF2
+type EncryptedStore struct {
+	inner CredentialStore
+
+	//unencrypted credentials are accepted and encrypted only if enabled, otherwise anyone who can write
+	//to the underlying store could replace a credential
+	migrate bool
+
+	key        []byte
+	passphrase []byte
+
+	//the passphrase is derived once per salt, new credentials use the salt of the process
+	mu          sync.Mutex
+	salt        []byte
+	derivedKeys map[string][]byte
+}
+
+//NewEncryptedStoreWithKey - key must be 32 bytes
+func NewEncryptedStoreWithKey(inner CredentialStore, key []byte) (*EncryptedStore, error) {
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("the encryption key must be %d bytes, got %d", keyLength, len(key))
+	}
+	return &EncryptedStore{inner: inner, key: key}, nil
+}
+
+//NewEncryptedStoreWithPassphrase -
+func NewEncryptedStoreWithPassphrase(inner CredentialStore, passphrase []byte) (*EncryptedStore, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("the encryption passphrase is empty")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedStore{inner: inner, passphrase: passphrase, salt: salt, derivedKeys: map[string][]byte{}}, nil
+}
+
+//ParseKey - accepts 32 raw bytes or their hex or base64 encoding, e.g. the output of openssl rand -base64 32
+func ParseKey(data []byte) ([]byte, error) {
+
+	if len(data) == keyLength {
+		return data, nil
+	}
+
+	encoded := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == keyLength {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == keyLength {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("the encryption key must be %d bytes, hex or base64 encoded", keyLength)
+}
+
+//MigrateUnencrypted - accepts credentials stored before the encryption was enabled and encrypts them on first use
+func (es *EncryptedStore) MigrateUnencrypted() {
+	es.migrate = true
+}
+
+//Load - decrypts the credential, unencrypted credentials are refused unless they are migrated
+func (es *EncryptedStore) Load(connection string, name string) ([]byte, error) {
+
+	data, err := es.inner.Load(connection, name)
+	if err != nil {
+		return nil, err
+	}
+
+	env, ok, err := parseEnvelope(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s of %s: %s", name, connection, err)
+	}
+	if !ok {
+		if !es.migrate {
+			return nil, fmt.Errorf("%s of %s: %w", name, connection, ErrNotEncrypted)
+		}
+		log.Printf("encrypting the unencrypted credential %s of %s", name, connection)
+		if err := es.Save(connection, name, data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	key, err := es.envelopeKey(env)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, env.Nonce, env.Ciphertext, additionalData(connection, name))
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt %s of %s, wrong passphrase or key", name, connection)
+	}
+	return plaintext, nil
+}
+
+//Save -
+func (es *EncryptedStore) Save(connection string, name string, data []byte) error {
+
+	env := envelope{Cipher: envelopeCipher, KDF: kdfNone}
+	key := es.key
+
+	if es.passphrase != nil {
+		env.KDF, env.Iterations, env.Salt = kdfPBKDF2, pbkdf2Iterations, es.salt
+		key = es.derive(es.salt, pbkdf2Iterations)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, data, additionalData(connection, name))
+
+	encrypted, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return es.inner.Save(connection, name, encrypted)
+}
+
+//Delete -
+func (es *EncryptedStore) Delete(connection string, name string) error {
+	return es.inner.Delete(connection, name)
+}
+
+//List -
+func (es *EncryptedStore) List(name string) ([]string, error) {
+	return es.inner.List(name)
+}
+
+//every json object with a cipher is an envelope, false if the data is no envelope at all
+func parseEnvelope(data []byte) (envelope, bool, error) {
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return envelope{}, false, nil
+	}
+	if _, ok := fields["cipher"]; !ok {
+		return envelope{}, false, nil
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return envelope{}, true, fmt.Errorf("invalid envelope: %s", err)
+	}
+	if env.Cipher != envelopeCipher {
+		return envelope{}, true, fmt.Errorf("unsupported cipher %q", env.Cipher)
+	}
+	return env, true, nil
+}
+
+func (es *EncryptedStore) envelopeKey(env envelope) ([]byte, error) {
+
+	switch env.KDF {
+	case kdfNone:
+		if es.key == nil {
+			return nil, errors.New("the credential was encrypted with a key, but a passphrase is configured")
+		}
+		return es.key, nil
+	case kdfPBKDF2:
+		if es.passphrase == nil {
+			return nil, errors.New("the credential was encrypted with a passphrase, but a key is configured")
+		}
+		if env.Iterations <= 0 || len(env.Salt) == 0 {
+			return nil, errors.New("invalid key derivation parameters")
+		}
+		return es.derive(env.Salt, env.Iterations), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key derivation %q", env.KDF)
+}
+
+func (es *EncryptedStore) derive(salt []byte, iterations int) []byte {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	cacheKey := fmt.Sprintf("%x/%d", salt, iterations)
+	if key, ok := es.derivedKeys[cacheKey]; ok {
+		return key
+	}
+
+	key := pbkdf2.Key(es.passphrase, salt, iterations, keyLength, sha256.New)
+	es.derivedKeys[cacheKey] = key
+	return key
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//binds the ciphertext to its location, so credentials cannot be swapped between connections
+func additionalData(connection string, name string) []byte {
+	return []byte(connection + "/" + name)
+}
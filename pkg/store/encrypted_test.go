@@ -0,0 +1,271 @@
+package store
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, keyLength)
+
+func newTestEncryptedStore(t *testing.T, inner CredentialStore) *EncryptedStore {
+	encrypted, err := NewEncryptedStoreWithKey(inner, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encrypted
+}
+
+func TestEncryptedStoreWithKey(t *testing.T) {
+
+	inner := NewMemoryStore()
+	encrypted := newTestEncryptedStore(t, inner)
+
+	if err := encrypted.Save("default", "private.key", []byte("secret key")); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := inner.Load("default", "private.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, []byte("secret key")) {
+		t.Errorf("the credential is stored in plain text: %s", stored)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(stored, &env); err != nil || env.Cipher != envelopeCipher || env.KDF != kdfNone {
+		t.Errorf("unexpected envelope %s: %v", stored, err)
+	}
+
+	data, err := encrypted.Load("default", "private.key")
+	if err != nil || string(data) != "secret key" {
+		t.Errorf("Load = %q, %v, want the saved key", data, err)
+	}
+
+	//every save uses a new nonce
+	if err := encrypted.Save("default", "private.key", []byte("secret key")); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := inner.Load("default", "private.key"); bytes.Equal(stored, again) {
+		t.Error("saving the same credential twice produced the same ciphertext")
+	}
+
+	other, err := NewEncryptedStoreWithKey(inner, bytes.Repeat([]byte{0x43}, keyLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Load("default", "private.key"); err == nil {
+		t.Error("the credential was decrypted with another key")
+	}
+}
+
+func TestEncryptedStoreWithPassphrase(t *testing.T) {
+
+	inner := NewMemoryStore()
+	encrypted, err := NewEncryptedStoreWithPassphrase(inner, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := encrypted.Save("default", "private.key", []byte("secret key")); err != nil {
+		t.Fatal(err)
+	}
+
+	//another process has its own salt but reads the credentials with the salt of their envelope
+	restarted, err := NewEncryptedStoreWithPassphrase(inner, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := restarted.Load("default", "private.key")
+	if err != nil || string(data) != "secret key" {
+		t.Errorf("Load after a restart = %q, %v, want the saved key", data, err)
+	}
+
+	wrong, err := NewEncryptedStoreWithPassphrase(inner, []byte("wrong"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrong.Load("default", "private.key"); err == nil {
+		t.Error("the credential was decrypted with a wrong passphrase")
+	}
+
+	if _, err := newTestEncryptedStore(t, inner).Load("default", "private.key"); err == nil {
+		t.Error("a credential encrypted with a passphrase was decrypted with a key")
+	}
+
+	if _, err := NewEncryptedStoreWithPassphrase(inner, nil); err == nil {
+		t.Error("an empty passphrase was accepted")
+	}
+}
+
+//an envelope of another PBKDF2 and AES-GCM implementation, the format and key derivation must stay compatible
+func TestEncryptedStoreReadsExistingEnvelopes(t *testing.T) {
+
+	const existing = `{"cipher":"AES-256-GCM","kdf":"PBKDF2-HMAC-SHA256","iterations":1000,` +
+		`"salt":"c2FsdHNhbHRzYWx0c2FsdA==","nonce":"AAECAwQFBgcICQoL","ciphertext":"Yt2dSrbpSCDKCLTMDpqIjmwKnGRo8QlhQRM="}`
+
+	inner := NewMemoryStore()
+	if err := inner.Save("default", "private.key", []byte(existing)); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := NewEncryptedStoreWithPassphrase(inner, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encrypted.Load("default", "private.key")
+	if err != nil || string(data) != "secret key" {
+		t.Errorf("Load = %q, %v, want the key of the existing envelope", data, err)
+	}
+}
+
+func TestEncryptedStoreRefusesTamperedCredentials(t *testing.T) {
+
+	inner := NewMemoryStore()
+	encrypted := newTestEncryptedStore(t, inner)
+
+	for _, connection := range []string{"first", "second"} {
+		for _, name := range []string{"private.key", "crtChain.crt"} {
+			if err := encrypted.Save(connection, name, []byte(connection+"/"+name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	firstKey, err := inner.Load("first", "private.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		connection string
+		credential string
+		data       func() []byte
+	}{
+		{"moved to another connection", "second", "private.key", func() []byte { return firstKey }},
+		{"moved to another credential", "first", "crtChain.crt", func() []byte { return firstKey }},
+		{"changed ciphertext", "first", "private.key", func() []byte {
+			var env envelope
+			json.Unmarshal(firstKey, &env)
+			env.Ciphertext[0] ^= 0x01
+			changed, _ := json.Marshal(env)
+			return changed
+		}},
+		{"changed nonce", "first", "private.key", func() []byte {
+			var env envelope
+			json.Unmarshal(firstKey, &env)
+			env.Nonce[0] ^= 0x01
+			changed, _ := json.Marshal(env)
+			return changed
+		}},
+		{"unknown key derivation", "first", "private.key", func() []byte {
+			var env envelope
+			json.Unmarshal(firstKey, &env)
+			env.KDF = "scrypt"
+			changed, _ := json.Marshal(env)
+			return changed
+		}},
+		{"changed cipher", "first", "private.key", func() []byte {
+			var env envelope
+			json.Unmarshal(firstKey, &env)
+			env.Cipher = "none"
+			changed, _ := json.Marshal(env)
+			return changed
+		}},
+		{"replaced by an unencrypted credential", "first", "private.key", func() []byte { return []byte("attacker key") }},
+		{"replaced by unencrypted json", "first", "private.key", func() []byte { return []byte(`{"key": "attacker key"}`) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := inner.Save(test.connection, test.credential, test.data()); err != nil {
+				t.Fatal(err)
+			}
+			if data, err := encrypted.Load(test.connection, test.credential); err == nil {
+				t.Errorf("the tampered credential was decrypted to %q", data)
+			}
+			if stored, _ := inner.Load(test.connection, test.credential); !bytes.Equal(stored, test.data()) {
+				t.Errorf("the tampered credential was replaced by %s", stored)
+			}
+		})
+	}
+}
+
+func TestEncryptedStoreRefusesUnencryptedCredentials(t *testing.T) {
+
+	inner := NewMemoryStore()
+	if err := inner.Save("default", "private.key", []byte("plain key")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := newTestEncryptedStore(t, inner).Load("default", "private.key"); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Load = %q, %v, want ErrNotEncrypted", data, err)
+	}
+	if stored, _ := inner.Load("default", "private.key"); string(stored) != "plain key" {
+		t.Errorf("the refused credential was replaced by %s", stored)
+	}
+}
+
+func TestEncryptedStoreMigratesUnencryptedCredentials(t *testing.T) {
+
+	inner := NewMemoryStore()
+	if err := inner.Save("default", "private.key", []byte("plain key")); err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := newTestEncryptedStore(t, inner)
+	encrypted.MigrateUnencrypted()
+
+	data, err := encrypted.Load("default", "private.key")
+	if err != nil || string(data) != "plain key" {
+		t.Fatalf("Load = %q, %v, want the unencrypted key", data, err)
+	}
+
+	stored, err := inner.Load("default", "private.key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, []byte("plain key")) {
+		t.Error("the unencrypted credential was not encrypted when it was read")
+	}
+
+	//envelopes are never migrated, a changed cipher stays an error
+	var env envelope
+	json.Unmarshal(stored, &env)
+	env.Cipher = "none"
+	changed, _ := json.Marshal(env)
+	if err := inner.Save("default", "private.key", changed); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := encrypted.Load("default", "private.key"); err == nil {
+		t.Errorf("the envelope with a changed cipher was migrated to %q", data)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+
+	for name, data := range map[string][]byte{
+		"raw":    testKey,
+		"hex":    []byte(hex.EncodeToString(testKey)),
+		"base64": []byte(base64.StdEncoding.EncodeToString(testKey) + "\n"),
+	} {
+		key, err := ParseKey(data)
+		if err != nil || !bytes.Equal(key, testKey) {
+			t.Errorf("ParseKey of the %s key = %x, %v", name, key, err)
+		}
+	}
+
+	for _, data := range []string{"", "short", hex.EncodeToString(testKey[:20])} {
+		if _, err := ParseKey([]byte(data)); err == nil {
+			t.Errorf("ParseKey(%q) accepted an invalid key", data)
+		}
+	}
+	if _, err := NewEncryptedStoreWithKey(NewMemoryStore(), testKey[:16]); err == nil {
+		t.Error("NewEncryptedStoreWithKey accepted a 16 byte key")
+	}
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+//FileStore - keeps the credentials as files readable only by the owner
+//the default connection uses the directory itself, all others get a sub directory
+type FileStore struct {
+	Dir               string
+	DefaultConnection string
+}
+
+//NewFileStore - stores the credentials below dir
+func NewFileStore(dir string, defaultConnection string) *FileStore {
+	return &FileStore{Dir: dir, DefaultConnection: defaultConnection}
+}
+
+func (fs *FileStore) connectionDir(connection string) string {
+	if connection == fs.DefaultConnection {
+		return fs.Dir
+	}
+	return filepath.Join(fs.Dir, connection)
+}
+
+//Load -
+func (fs *FileStore) Load(connection string, name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(fs.connectionDir(connection), name))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+//Save - writes the file with mode 0600, an existing file is replaced
+func (fs *FileStore) Save(connection string, name string, data []byte) error {
+
+	dir := fs.connectionDir(connection)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	//files written by earlier versions may still be world readable
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0600)
+}
+
+//Delete - removes the file and the directory of the connection once it is empty
+func (fs *FileStore) Delete(connection string, name string) error {
+
+	dir := fs.connectionDir(connection)
+
+	err := os.Remove(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if dir != fs.Dir {
+		//only succeeds once the directory is empty
+		os.Remove(dir)
+	}
+	return nil
+}
+
+//List -
+func (fs *FileStore) List(name string) ([]string, error) {
+
+	var connections []string
+	if _, err := os.Stat(filepath.Join(fs.Dir, name)); err == nil {
+		connections = append(connections, fs.DefaultConnection)
+	}
+
+	entries, err := ioutil.ReadDir(fs.Dir)
+	if os.IsNotExist(err) {
+		return connections, nil
+	}
+	if err != nil {
+		return connections, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == fs.DefaultConnection {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(fs.Dir, entry.Name(), name)); err == nil {
+			connections = append(connections, entry.Name())
+		}
+	}
+
+	sort.Strings(connections)
+	return connections, nil
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+//Secret - the parts of a kubernetes secret used by the SecretStore
+type Secret struct {
+	Name            string
+	Labels          map[string]string
+	Annotations     map[string]string
+	Data            map[string][]byte
+	ResourceVersion string
+}
+
+//SecretClient - the secret operations of a namespace used by the SecretStore
+//GetSecret returns ErrNotFound for missing secrets, create and update return ErrConflict if the secret was changed
+type SecretClient interface {
+	GetSecret(name string) (*Secret, error)
+	CreateSecret(secret *Secret) error
+	UpdateSecret(secret *Secret) error
+	DeleteSecret(name string) error
+	ListSecrets(labelSelector string) ([]Secret, error)
+}
+
+const serviceAccountNamespaceFile string = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+//kubeSecretClient - the secrets of a namespace of the kubernetes api server
+type kubeSecretClient struct {
+	secrets typedcorev1.SecretInterface
+}
+
+//NewInClusterSecretClient - uses the service account of the pod, namespace defaults to the one of the pod
+func NewInClusterSecretClient(namespace string) (SecretClient, error) {
+
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("not running in a kubernetes cluster: %s", err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		podNamespace, err := ioutil.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not determine the namespace: %s", err)
+		}
+		namespace = strings.TrimSpace(string(podNamespace))
+	}
+
+	return NewSecretClient(clientset, namespace), nil
+}
+
+//NewSecretClient - the secrets of the namespace of the clientset
+func NewSecretClient(clientset kubernetes.Interface, namespace string) SecretClient {
+	return &kubeSecretClient{secrets: clientset.CoreV1().Secrets(namespace)}
+}
+
+//translates the status errors the SecretStore handles
+func secretError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err):
+		return ErrNotFound
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return ErrConflict
+	}
+	return err
+}
+
+func (kc *kubeSecretClient) GetSecret(name string) (*Secret, error) {
+	secret, err := kc.secrets.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return toSecret(secret), nil
+}
+
+func (kc *kubeSecretClient) CreateSecret(secret *Secret) error {
+	_, err := kc.secrets.Create(context.Background(), toKubeSecret(secret), metav1.CreateOptions{})
+	return secretError(err)
+}
+
+func (kc *kubeSecretClient) UpdateSecret(secret *Secret) error {
+	_, err := kc.secrets.Update(context.Background(), toKubeSecret(secret), metav1.UpdateOptions{})
+	return secretError(err)
+}
+
+func (kc *kubeSecretClient) DeleteSecret(name string) error {
+	return secretError(kc.secrets.Delete(context.Background(), name, metav1.DeleteOptions{}))
+}
+
+func (kc *kubeSecretClient) ListSecrets(labelSelector string) ([]Secret, error) {
+	list, err := kc.secrets.List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, secretError(err)
+	}
+
+	secrets := []Secret{}
+	for i := range list.Items {
+		secrets = append(secrets, *toSecret(&list.Items[i]))
+	}
+	return secrets, nil
+}
+
+func toKubeSecret(secret *Secret) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            secret.Name,
+			Labels:          secret.Labels,
+			Annotations:     secret.Annotations,
+			ResourceVersion: secret.ResourceVersion,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: secret.Data,
+	}
+}
+
+func toSecret(secret *corev1.Secret) *Secret {
+	return &Secret{
+		Name:            secret.Name,
+		Labels:          secret.Labels,
+		Annotations:     secret.Annotations,
+		Data:            secret.Data,
+		ResourceVersion: secret.ResourceVersion,
+	}
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+//fakeSecretClient - in memory SecretClient, behaves like the api server for the operations used
+type fakeSecretClient struct {
+	mu      sync.Mutex
+	secrets map[string]Secret
+	version int
+	//the next writes fail with ErrConflict as if the secret was changed concurrently
+	conflicts int
+}
+
+func newFakeSecretClient(secrets ...Secret) *fakeSecretClient {
+	fake := &fakeSecretClient{secrets: map[string]Secret{}}
+	for _, secret := range secrets {
+		fake.CreateSecret(&secret)
+	}
+	return fake
+}
+
+func (fake *fakeSecretClient) GetSecret(name string) (*Secret, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	secret, ok := fake.secrets[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copySecret(secret), nil
+}
+
+func (fake *fakeSecretClient) CreateSecret(secret *Secret) error {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if _, ok := fake.secrets[secret.Name]; ok || fake.conflict() {
+		return ErrConflict
+	}
+	fake.store(secret)
+	return nil
+}
+
+func (fake *fakeSecretClient) UpdateSecret(secret *Secret) error {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	existing, ok := fake.secrets[secret.Name]
+	if !ok {
+		return ErrNotFound
+	}
+	if secret.ResourceVersion != existing.ResourceVersion || fake.conflict() {
+		return ErrConflict
+	}
+	fake.store(secret)
+	return nil
+}
+
+func (fake *fakeSecretClient) DeleteSecret(name string) error {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if _, ok := fake.secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(fake.secrets, name)
+	return nil
+}
+
+//supports selectors of the form key=value[,key=value]
+func (fake *fakeSecretClient) ListSecrets(labelSelector string) ([]Secret, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	names := make([]string, 0, len(fake.secrets))
+	for name := range fake.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	secrets := []Secret{}
+	for _, name := range names {
+		secret := fake.secrets[name]
+		if matchesSelector(secret.Labels, labelSelector) {
+			secrets = append(secrets, *copySecret(secret))
+		}
+	}
+	return secrets, nil
+}
+
+func (fake *fakeSecretClient) conflict() bool {
+	if fake.conflicts == 0 {
+		return false
+	}
+	fake.conflicts--
+	return true
+}
+
+func (fake *fakeSecretClient) store(secret *Secret) {
+	fake.version++
+	stored := copySecret(*secret)
+	stored.ResourceVersion = fmt.Sprint(fake.version)
+	fake.secrets[secret.Name] = *stored
+}
+
+func matchesSelector(labels map[string]string, labelSelector string) bool {
+	for _, requirement := range strings.Split(labelSelector, ",") {
+		if requirement == "" {
+			continue
+		}
+		parts := strings.SplitN(requirement, "=", 2)
+		if len(parts) != 2 || labels[parts[0]] != parts[1] {
+			return false
+		}
+	}
+	return true
+}
+
+func copySecret(secret Secret) *Secret {
+	copied := &Secret{Name: secret.Name, ResourceVersion: secret.ResourceVersion, Labels: map[string]string{}, Annotations: map[string]string{}, Data: map[string][]byte{}}
+	for key, value := range secret.Labels {
+		copied.Labels[key] = value
+	}
+	for key, value := range secret.Annotations {
+		copied.Annotations[key] = value
+	}
+	for key, value := range secret.Data {
+		copied.Data[key] = append([]byte(nil), value...)
+	}
+	return copied
+}
+
+func TestKubeSecretClient(t *testing.T) {
+
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kyma-app-conn-default", Namespace: "other"},
+	})
+	secretStore, err := NewSecretStore(NewSecretClient(clientset, "demo"), "kyma-app-conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := secretStore.Save("default", "private.key", []byte("key")); err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+	if err := secretStore.Save("default", "crtChain.crt", []byte("chain")); err != nil {
+		t.Fatalf("Save: %s", err)
+	}
+
+	stored, err := clientset.CoreV1().Secrets("demo").Get(context.Background(), "kyma-app-conn-default", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("the secret was not created: %s", err)
+	}
+	if string(stored.Data["private.key"]) != "key" || string(stored.Data["crtChain.crt"]) != "chain" {
+		t.Errorf("unexpected secret data %v", stored.Data)
+	}
+	if stored.Type != corev1.SecretTypeOpaque || stored.Annotations[connectionAnnotation] != "default" || stored.Labels[managedByLabel] != managedBy {
+		t.Errorf("unexpected type %s, labels %v and annotations %v", stored.Type, stored.Labels, stored.Annotations)
+	}
+
+	data, err := secretStore.Load("default", "private.key")
+	if err != nil || string(data) != "key" {
+		t.Errorf("Load = %q, %v", data, err)
+	}
+
+	connections, err := secretStore.List("private.key")
+	if err != nil || !reflect.DeepEqual(connections, []string{"default"}) {
+		t.Errorf("List = %v, %v", connections, err)
+	}
+
+	if _, err := secretStore.Load("other", "private.key"); err != ErrNotFound {
+		t.Errorf("Load of a missing secret = %v, want ErrNotFound", err)
+	}
+
+	for _, name := range []string{"private.key", "crtChain.crt"} {
+		if err := secretStore.Delete("default", name); err != nil {
+			t.Fatalf("Delete %s: %s", name, err)
+		}
+	}
+	if _, err := clientset.CoreV1().Secrets("demo").Get(context.Background(), "kyma-app-conn-default", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("the secret was not deleted with the last credential: %v", err)
+	}
+	if _, err := clientset.CoreV1().Secrets("other").Get(context.Background(), "kyma-app-conn-default", metav1.GetOptions{}); err != nil {
+		t.Errorf("the secret of another namespace was changed: %v", err)
+	}
+}
+
+func TestKubeSecretClientErrors(t *testing.T) {
+
+	secret := &Secret{Name: "kyma-app-conn-default", Data: map[string][]byte{"private.key": []byte("key")}}
+
+	clientset := fake.NewSimpleClientset()
+	client := NewSecretClient(clientset, "demo")
+
+	if err := client.CreateSecret(secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateSecret(secret); err != ErrConflict {
+		t.Errorf("CreateSecret of an existing secret = %v, want ErrConflict", err)
+	}
+
+	//the api server refuses updates of a changed resource version
+	clientset.PrependReactor("update", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(corev1.Resource("secrets"), secret.Name, errors.New("the object has been modified"))
+	})
+	if err := client.UpdateSecret(secret); err != ErrConflict {
+		t.Errorf("UpdateSecret of a changed secret = %v, want ErrConflict", err)
+	}
+
+	clientset.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewUnauthorized("invalid token")
+	})
+	if _, err := client.GetSecret(secret.Name); err == nil || err == ErrNotFound || !apierrors.IsUnauthorized(err) {
+		t.Errorf("GetSecret with a wrong token = %v, want the unauthorized error", err)
+	}
+
+	if err := client.DeleteSecret("kyma-app-conn-missing"); err != ErrNotFound {
+		t.Errorf("DeleteSecret of a missing secret = %v, want ErrNotFound", err)
+	}
+}
+
+func TestNewInClusterSecretClientOutsideCluster(t *testing.T) {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		t.Skip("running in a cluster")
+	}
+	if _, err := NewInClusterSecretClient(""); err == nil {
+		t.Error("NewInClusterSecretClient succeeded outside a cluster")
+	}
+}
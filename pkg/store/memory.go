@@ -0,0 +1,73 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+//MemoryStore - keeps the credentials in memory only, connections are lost when the app stops
+type MemoryStore struct {
+	mu          sync.RWMutex
+	credentials map[string]map[string][]byte
+}
+
+//NewMemoryStore -
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{credentials: map[string]map[string][]byte{}}
+}
+
+//Load -
+func (ms *MemoryStore) Load(connection string, name string) ([]byte, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	data, ok := ms.credentials[connection][name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), data...), nil
+}
+
+//Save -
+func (ms *MemoryStore) Save(connection string, name string, data []byte) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.credentials[connection] == nil {
+		ms.credentials[connection] = map[string][]byte{}
+	}
+	ms.credentials[connection][name] = append([]byte(nil), data...)
+	return nil
+}
+
+//Delete -
+func (ms *MemoryStore) Delete(connection string, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.credentials[connection][name]; !ok {
+		return ErrNotFound
+	}
+
+	delete(ms.credentials[connection], name)
+	if len(ms.credentials[connection]) == 0 {
+		delete(ms.credentials, connection)
+	}
+	return nil
+}
+
+//List -
+func (ms *MemoryStore) List(name string) ([]string, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	var connections []string
+	for connection, credentials := range ms.credentials {
+		if _, ok := credentials[name]; ok {
+			connections = append(connections, connection)
+		}
+	}
+
+	sort.Strings(connections)
+	return connections, nil
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//labels and annotations of the secrets created by the SecretStore
+//the connection is kept in an annotation, label values are limited to 63 characters
+const (
+	managedByLabel       string = "app.kubernetes.io/managed-by"
+	managedBy            string = "kyma-app-conn-demo"
+	connectionLabel      string = "kyma-app-conn-demo/connection"
+	connectionAnnotation string = "kyma-app-conn-demo/connection"
+)
+
+//secret names are dns subdomains of at most 253 characters, the prefix is a dns label
+const maxSecretNameLength int = 253
+const maxSecretPrefixLength int = 63
+
+var secretPrefixRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+var invalidSecretNameRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+var labelValueRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([-_.a-zA-Z0-9]{0,61}[a-zA-Z0-9])?$`)
+
+//SecretStore - keeps the credentials of each connection in a kubernetes secret, one key per credential
+type SecretStore struct {
+	Client SecretClient
+	//the secrets are named <Prefix>-<connection>
+	Prefix string
+}
+
+//NewSecretStore - the prefix must be a lower case dns label
+func NewSecretStore(client SecretClient, prefix string) (*SecretStore, error) {
+	if len(prefix) > maxSecretPrefixLength || !secretPrefixRegexp.MatchString(prefix) {
+		return nil, fmt.Errorf("invalid secret prefix %q, use at most %d lower case letters, digits and dashes", prefix, maxSecretPrefixLength)
+	}
+	return &SecretStore{Client: client, Prefix: prefix}, nil
+}
+
+//secret names must be lower case dns names, names that had to be changed or shortened get a hash to stay unique
+func (ss *SecretStore) secretName(connection string) string {
+
+	name := strings.Trim(invalidSecretNameRegexp.ReplaceAllString(strings.ToLower(connection), "-"), "-")
+	if name == connection && len(ss.Prefix)+1+len(name) <= maxSecretNameLength {
+		return ss.Prefix + "-" + name
+	}
+
+	hash := sha256.Sum256([]byte(connection))
+	suffix := hex.EncodeToString(hash[:4])
+
+	//room for the prefix, the hash and the dashes between them
+	if maxLength := maxSecretNameLength - len(ss.Prefix) - len(suffix) - 2; len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], "-")
+	}
+	if name == "" {
+		return ss.Prefix + "-" + suffix
+	}
+	return ss.Prefix + "-" + name + "-" + suffix
+}
+
+//Load -
+func (ss *SecretStore) Load(connection string, name string) ([]byte, error) {
+
+	secret, err := ss.Client.GetSecret(ss.secretName(connection))
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := secret.Data[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+//Save - creates the secret of the connection or adds the credential to it
+func (ss *SecretStore) Save(connection string, name string, data []byte) error {
+
+	return ss.update(connection, func(secret *Secret) bool {
+		secret.Data[name] = data
+		return true
+	})
+}
+
+//Delete - removes the credential, the secret is deleted with the last one
+func (ss *SecretStore) Delete(connection string, name string) error {
+
+	found := false
+	err := ss.update(connection, func(secret *Secret) bool {
+		_, found = secret.Data[name]
+		delete(secret.Data, name)
+		return found
+	})
+
+	if err == nil && !found {
+		return ErrNotFound
+	}
+	return err
+}
+
+//applies the change to the current secret, retried once if the secret was changed concurrently
+func (ss *SecretStore) update(connection string, change func(secret *Secret) bool) error {
+
+	secretName := ss.secretName(connection)
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+
+		secret, getErr := ss.Client.GetSecret(secretName)
+		exists := getErr == nil
+		if getErr == ErrNotFound {
+			secret = &Secret{
+				Name:        secretName,
+				Labels:      map[string]string{managedByLabel: managedBy},
+				Annotations: map[string]string{connectionAnnotation: connection},
+			}
+			if labelValueRegexp.MatchString(connection) {
+				secret.Labels[connectionLabel] = connection
+			}
+		} else if getErr != nil {
+			return getErr
+		}
+
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+
+		if !change(secret) {
+			return nil
+		}
+
+		switch {
+		case !exists && len(secret.Data) == 0:
+			return nil
+		case !exists:
+			err = ss.Client.CreateSecret(secret)
+		case len(secret.Data) == 0:
+			err = ss.Client.DeleteSecret(secretName)
+		default:
+			err = ss.Client.UpdateSecret(secret)
+		}
+
+		if err != ErrConflict {
+			return err
+		}
+	}
+
+	return err
+}
+
+//List -
+func (ss *SecretStore) List(name string) ([]string, error) {
+
+	secrets, err := ss.Client.ListSecrets(managedByLabel + "=" + managedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	var connections []string
+	for _, secret := range secrets {
+		connection := secret.Annotations[connectionAnnotation]
+		if _, ok := secret.Data[name]; ok && connection != "" && strings.HasPrefix(secret.Name, ss.Prefix+"-") {
+			connections = append(connections, connection)
+		}
+	}
+
+	sort.Strings(connections)
+	return connections, nil
+}
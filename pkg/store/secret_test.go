@@ -0,0 +1,190 @@
+package store
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+//a dns-1123 subdomain as required for secret names
+var dnsSubdomainRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+func newTestSecretStore(t *testing.T, client SecretClient) *SecretStore {
+	secretStore, err := NewSecretStore(client, "kyma-app-conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secretStore
+}
+
+func TestSecretStoreSaveLoadDelete(t *testing.T) {
+
+	secrets := newFakeSecretClient()
+	secretStore := newTestSecretStore(t, secrets)
+
+	if _, err := secretStore.Load("default", "private.key"); err != ErrNotFound {
+		t.Errorf("Load before Save = %v, want ErrNotFound", err)
+	}
+
+	for name, data := range map[string]string{"private.key": "key", "crtChain.crt": "chain"} {
+		if err := secretStore.Save("default", name, []byte(data)); err != nil {
+			t.Fatalf("Save %s: %s", name, err)
+		}
+	}
+	if err := secretStore.Save("default", "private.key", []byte("new key")); err != nil {
+		t.Fatalf("Save of an existing credential: %s", err)
+	}
+
+	data, err := secretStore.Load("default", "private.key")
+	if err != nil || string(data) != "new key" {
+		t.Errorf("Load = %q, %v, want the saved key", data, err)
+	}
+	if _, err := secretStore.Load("default", "ca.crt"); err != ErrNotFound {
+		t.Errorf("Load of a missing credential = %v, want ErrNotFound", err)
+	}
+
+	if err := secretStore.Delete("default", "ca.crt"); err != ErrNotFound {
+		t.Errorf("Delete of a missing credential = %v, want ErrNotFound", err)
+	}
+	if err := secretStore.Delete("default", "private.key"); err != nil {
+		t.Fatalf("Delete: %s", err)
+	}
+	if _, err := secretStore.Load("default", "private.key"); err != ErrNotFound {
+		t.Errorf("Load after Delete = %v, want ErrNotFound", err)
+	}
+	if _, err := secrets.GetSecret("kyma-app-conn-default"); err != nil {
+		t.Errorf("the secret was deleted with credentials left: %s", err)
+	}
+
+	if err := secretStore.Delete("default", "crtChain.crt"); err != nil {
+		t.Fatalf("Delete: %s", err)
+	}
+	if _, err := secrets.GetSecret("kyma-app-conn-default"); err != ErrNotFound {
+		t.Errorf("the secret was not deleted with the last credential: %v", err)
+	}
+}
+
+func TestSecretStoreRetriesConflicts(t *testing.T) {
+
+	secrets := newFakeSecretClient()
+	secretStore := newTestSecretStore(t, secrets)
+
+	secrets.conflicts = 1
+	if err := secretStore.Save("default", "private.key", []byte("key")); err != nil {
+		t.Errorf("Save with one conflict: %s", err)
+	}
+
+	secrets.conflicts = 2
+	if err := secretStore.Save("default", "private.key", []byte("other key")); err != ErrConflict {
+		t.Errorf("Save with two conflicts = %v, want ErrConflict", err)
+	}
+
+	data, err := secretStore.Load("default", "private.key")
+	if err != nil || string(data) != "key" {
+		t.Errorf("Load = %q, %v, the failed Save must not change the secret", data, err)
+	}
+}
+
+func TestSecretStoreList(t *testing.T) {
+
+	secrets := newFakeSecretClient(
+		//without the annotation the connection is not known
+		Secret{
+			Name:   "kyma-app-conn-old",
+			Labels: map[string]string{managedByLabel: managedBy, connectionLabel: "old"},
+			Data:   map[string][]byte{"connection.json": []byte("{}")},
+		},
+		//another prefix
+		Secret{
+			Name:        "other-prefix-default",
+			Labels:      map[string]string{managedByLabel: managedBy},
+			Annotations: map[string]string{connectionAnnotation: "default"},
+			Data:        map[string][]byte{"connection.json": []byte("{}")},
+		},
+	)
+	secretStore := newTestSecretStore(t, secrets)
+
+	long := strings.Repeat("c", 100)
+	for _, connection := range []string{"default", "-Upper_", long} {
+		if err := secretStore.Save(connection, "connection.json", []byte("{}")); err != nil {
+			t.Fatalf("Save %s: %s", connection, err)
+		}
+	}
+	if err := secretStore.Save("keys-only", "private.key", []byte("key")); err != nil {
+		t.Fatal(err)
+	}
+
+	connections, err := secretStore.List("connection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"-Upper_", long, "default"}
+	if !reflect.DeepEqual(connections, expected) {
+		t.Errorf("List = %v, want %v", connections, expected)
+	}
+
+	//label values are limited to 63 characters and must start and end with a letter or digit
+	for _, connection := range []string{"-Upper_", long} {
+		secret, err := secrets.GetSecret(secretStore.secretName(connection))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := secret.Labels[connectionLabel]; ok {
+			t.Errorf("the secret of %s has the invalid label value %q", connection, secret.Labels[connectionLabel])
+		}
+	}
+}
+
+func TestSecretName(t *testing.T) {
+
+	secretStore := newTestSecretStore(t, newFakeSecretClient())
+
+	tests := []struct {
+		connection string
+		expected   string
+	}{
+		{"default", "kyma-app-conn-default"},
+		{"my-app-2", "kyma-app-conn-my-app-2"},
+		{"MyApp", "kyma-app-conn-myapp-"},
+		{"my_app", "kyma-app-conn-my-app-"},
+		{"-app-", "kyma-app-conn-app-"},
+		{"__", "kyma-app-conn-"},
+	}
+
+	for _, test := range tests {
+		name := secretStore.secretName(test.connection)
+		if name != test.expected && !(strings.HasSuffix(test.expected, "-") && strings.HasPrefix(name, test.expected)) {
+			t.Errorf("secretName(%q) = %q, want %q", test.connection, name, test.expected)
+		}
+		if !dnsSubdomainRegexp.MatchString(name) {
+			t.Errorf("secretName(%q) = %q is no valid secret name", test.connection, name)
+		}
+	}
+
+	//changed names get a hash so they do not clash with the unchanged one
+	if secretStore.secretName("MyApp") == secretStore.secretName("myapp") {
+		t.Error("MyApp and myapp have the same secret name")
+	}
+
+	long := strings.Repeat("a", 300)
+	names := map[string]bool{}
+	for _, connection := range []string{long, long + "b", long[:240] + "-" + long[:20]} {
+		name := secretStore.secretName(connection)
+		if len(name) > maxSecretNameLength || !dnsSubdomainRegexp.MatchString(name) {
+			t.Errorf("secretName of a %d character connection = %q (%d characters) is no valid secret name", len(connection), name, len(name))
+		}
+		names[name] = true
+	}
+	if len(names) != 3 {
+		t.Errorf("shortened names clash: %v", names)
+	}
+}
+
+func TestNewSecretStoreRejectsInvalidPrefixes(t *testing.T) {
+	for _, prefix := range []string{"", "Kyma", "-kyma", "kyma-", "kyma.app", strings.Repeat("k", 64)} {
+		if _, err := NewSecretStore(newFakeSecretClient(), prefix); err == nil {
+			t.Errorf("NewSecretStore accepted the prefix %q", prefix)
+		}
+	}
+}
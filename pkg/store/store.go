@@ -0,0 +1,19 @@
+package store
+
+import "errors"
+
+//ErrNotFound - returned by Load and Delete if the connection has no credential of that name
+var ErrNotFound = errors.New("credential not found")
+
+//ErrConflict - the secret was changed or created since it was read
+var ErrConflict = errors.New("secret was modified concurrently")
+
+//CredentialStore - persists the certificates, keys and metadata of the connections
+//credentials are addressed by the name of the connection and a file like name, e.g. private.key
+type CredentialStore interface {
+	Load(connection string, name string) ([]byte, error)
+	Save(connection string, name string, data []byte) error
+	Delete(connection string, name string) error
+	//List - the connections that have a credential of the given name
+	List(name string) ([]string, error)
+}
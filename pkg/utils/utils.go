@@ -20,3 +20,15 @@ func ReturnSuccess(message string, w http.ResponseWriter) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+//ReturnJSON -
+func ReturnJSON(data interface{}, w http.ResponseWriter) {
+	ReturnJSONWithStatus(data, http.StatusOK, w)
+}
+
+//ReturnJSONWithStatus -
+func ReturnJSONWithStatus(data interface{}, status int, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
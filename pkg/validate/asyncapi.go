@@ -0,0 +1,145 @@
+package validate
+
+import (
+	"regexp"
+	"strings"
+)
+
+var asyncAPI1Version = regexp.MustCompile(`^1\.[0-2]\.\d+$`)
+
+var asyncAPI2Version = regexp.MustCompile(`^2\.\d+\.\d+$`)
+
+//AsyncAPI - checks an AsyncAPI 1.x or 2.x document in json or yaml
+func AsyncAPI(document []byte) []Problem {
+	root, problems := parseObject(document)
+	if problems != nil {
+		return problems
+	}
+
+	c := &checker{root: root}
+	c.asyncAPI(root, "")
+	return c.problems
+}
+
+//checks the structure required by the AsyncAPI specification of the declared version, paths are prefixed with path
+func (c *checker) asyncAPI(document map[string]interface{}, path string) {
+
+	version := c.stringField(document, path, "asyncapi", true)
+
+	switch {
+	case asyncAPI1Version.MatchString(version):
+		c.asyncAPI1(document, path)
+	case asyncAPI2Version.MatchString(version):
+		c.asyncAPI2(document, path)
+	case version != "":
+		c.add(joinPath(path, "asyncapi"), "version %q is not supported, AsyncAPI 1.x or 2.x is required", version)
+	}
+
+	c.info(document, path)
+	c.references(document, path)
+}
+
+//1.x documents describe topics, a stream or events
+func (c *checker) asyncAPI1(document map[string]interface{}, path string) {
+
+	_, hasTopics := document["topics"]
+	_, hasStream := document["stream"]
+	_, hasEvents := document["events"]
+
+	if !hasTopics && !hasStream && !hasEvents {
+		c.add(joinPath(path, "topics"), "one of topics, stream or events is required")
+		return
+	}
+
+	topics := c.objectField(document, path, "topics", false)
+	for _, name := range sortedKeys(topics) {
+		topicPath := joinPath(joinPath(path, "topics"), name)
+
+		topic := c.object(topics[name], topicPath)
+		if topic == nil {
+			continue
+		}
+
+		if _, ok := topic["$ref"]; ok {
+			continue
+		}
+
+		_, hasPublish := topic["publish"]
+		_, hasSubscribe := topic["subscribe"]
+		if !hasPublish && !hasSubscribe {
+			c.add(topicPath, "publish or subscribe is required")
+		}
+
+		for _, operation := range []string{"publish", "subscribe"} {
+			if message, ok := topic[operation]; ok {
+				c.message(message, joinPath(topicPath, operation))
+			}
+		}
+	}
+}
+
+//2.x documents describe channels
+func (c *checker) asyncAPI2(document map[string]interface{}, path string) {
+
+	channels := c.objectField(document, path, "channels", true)
+	for _, name := range sortedKeys(channels) {
+		channelPath := joinPath(joinPath(path, "channels"), name)
+
+		if strings.TrimSpace(name) == "" {
+			c.add(channelPath, "channel name must not be empty")
+		}
+
+		channel := c.object(channels[name], channelPath)
+		if channel == nil {
+			continue
+		}
+
+		for _, operationName := range []string{"publish", "subscribe"} {
+			value, ok := channel[operationName]
+			if !ok {
+				continue
+			}
+
+			operationPath := joinPath(channelPath, operationName)
+			operation := c.object(value, operationPath)
+			if operation == nil {
+				continue
+			}
+
+			if message, ok := operation["message"]; ok {
+				c.message(message, joinPath(operationPath, "message"))
+			}
+		}
+	}
+}
+
+//messages are objects, possibly references or a oneOf list, with an object payload
+func (c *checker) message(value interface{}, path string) {
+
+	resolved := c.dereference(value)
+	if resolved == nil {
+		return
+	}
+
+	message := c.object(resolved, path)
+	if message == nil {
+		return
+	}
+
+	if oneOf, ok := message["oneOf"]; ok {
+		messages, ok := oneOf.([]interface{})
+		if !ok {
+			c.add(joinPath(path, "oneOf"), "must be a list")
+		}
+		for _, item := range messages {
+			c.message(item, joinPath(path, "oneOf"))
+		}
+		return
+	}
+
+	if payload, ok := message["payload"]; ok {
+		if resolvedPayload := c.dereference(payload); resolvedPayload != nil {
+			c.object(resolvedPayload, joinPath(path, "payload"))
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package validate
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var openAPIVersion = regexp.MustCompile(`^3\.\d+\.\d+$`)
+
+var responseCode = regexp.MustCompile(`^([1-5]\d\d|[1-5]XX|default)$`)
+
+var pathTemplate = regexp.MustCompile(`\{([^}]+)\}`)
+
+var operations = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+var parameterLocations = map[string]bool{"query": true, "header": true, "path": true, "cookie": true}
+
+//OpenAPI - checks an OpenAPI 3 document in json or yaml
+func OpenAPI(document []byte) []Problem {
+	root, problems := parseObject(document)
+	if problems != nil {
+		return problems
+	}
+
+	c := &checker{root: root}
+	c.openAPI(root, "")
+	return c.problems
+}
+
+//checks the structure required by the OpenAPI 3 specification, paths are prefixed with path
+func (c *checker) openAPI(document map[string]interface{}, path string) {
+
+	if _, ok := document["swagger"]; ok {
+		c.add(joinPath(path, "swagger"), "Swagger 2.0 documents are not supported, OpenAPI 3.x is required")
+		return
+	}
+
+	if version := c.stringField(document, path, "openapi", true); version != "" && !openAPIVersion.MatchString(version) {
+		c.add(joinPath(path, "openapi"), "version %q is not supported, OpenAPI 3.x is required", version)
+	}
+
+	c.info(document, path)
+
+	if servers, ok := document["servers"]; ok {
+		serverList, ok := servers.([]interface{})
+		if !ok {
+			c.add(joinPath(path, "servers"), "must be a list")
+		}
+		for i, server := range serverList {
+			serverPath := fmt.Sprintf("%s[%d]", joinPath(path, "servers"), i)
+			if serverObject := c.object(server, serverPath); serverObject != nil {
+				c.stringField(serverObject, serverPath, "url", true)
+			}
+		}
+	}
+
+	if components, ok := document["components"]; ok {
+		c.object(components, joinPath(path, "components"))
+	}
+
+	paths := c.objectField(document, path, "paths", true)
+	for _, route := range sortedKeys(paths) {
+		c.pathItem(route, paths[route], joinPath(joinPath(path, "paths"), route))
+	}
+
+	c.references(document, path)
+}
+
+func (c *checker) pathItem(route string, value interface{}, path string) {
+
+	if len(route) == 0 || route[0] != '/' {
+		c.add(path, "path must start with /")
+	}
+
+	item := c.object(value, path)
+	if item == nil {
+		return
+	}
+
+	pathParameters := c.parameters(item["parameters"], joinPath(path, "parameters"))
+
+	for _, method := range operations {
+		value, ok := item[method]
+		if !ok {
+			continue
+		}
+
+		operationPath := joinPath(path, method)
+		operation := c.object(value, operationPath)
+		if operation == nil {
+			continue
+		}
+
+		operationParameters := c.parameters(operation["parameters"], joinPath(operationPath, "parameters"))
+
+		for _, match := range pathTemplate.FindAllStringSubmatch(route, -1) {
+			if !pathParameters[match[1]] && !operationParameters[match[1]] {
+				c.add(joinPath(operationPath, "parameters"), "path parameter %q is not declared", match[1])
+			}
+		}
+
+		c.responses(operation, operationPath)
+	}
+}
+
+//checks the parameters and returns the names of the path parameters
+func (c *checker) parameters(value interface{}, path string) map[string]bool {
+	names := map[string]bool{}
+
+	if value == nil {
+		return names
+	}
+
+	parameters, ok := value.([]interface{})
+	if !ok {
+		c.add(path, "must be a list")
+		return names
+	}
+
+	for i, parameter := range parameters {
+		parameterPath := fmt.Sprintf("%s[%d]", path, i)
+
+		resolved := c.dereference(parameter)
+		if resolved == nil {
+			continue
+		}
+		parameterObject := c.object(resolved, parameterPath)
+		if parameterObject == nil {
+			continue
+		}
+
+		name := c.stringField(parameterObject, parameterPath, "name", true)
+		location := c.stringField(parameterObject, parameterPath, "in", true)
+
+		if location != "" && !parameterLocations[location] {
+			c.add(joinPath(parameterPath, "in"), "must be query, header, path or cookie, not %q", location)
+		}
+
+		if location == "path" {
+			if required, _ := parameterObject["required"].(bool); !required {
+				c.add(joinPath(parameterPath, "required"), "path parameters must be required")
+			}
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+func (c *checker) responses(operation map[string]interface{}, path string) {
+
+	responses := c.objectField(operation, path, "responses", true)
+	if responses == nil {
+		return
+	}
+
+	responsesPath := joinPath(path, "responses")
+	if len(responses) == 0 {
+		c.add(responsesPath, "at least one response is required")
+	}
+
+	for _, code := range sortedKeys(responses) {
+		responsePath := joinPath(responsesPath, code)
+
+		if !responseCode.MatchString(code) {
+			c.add(responsePath, "%q is not a status code", code)
+		}
+
+		resolved := c.dereference(responses[code])
+		if resolved == nil {
+			continue
+		}
+		//an empty description is allowed
+		if response := c.object(resolved, responsePath); response != nil {
+			if _, ok := response["description"]; !ok {
+				c.add(joinPath(responsePath, "description"), "is required")
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package validate
+
+import (
+	"net/url"
+	"strings"
+)
+
+//Service - checks a rest service envelope, the api spec (unless odata) and the event spec are checked as well
+func Service(document []byte) []Problem {
+	root, problems := parseObject(document)
+	if problems != nil {
+		return problems
+	}
+
+	c := &checker{root: root}
+	c.stringField(root, "", "provider", true)
+	c.stringField(root, "", "name", true)
+
+	if labels := c.objectField(root, "", "labels", false); labels != nil {
+		for _, key := range sortedKeys(labels) {
+			if _, ok := labels[key].(string); !ok {
+				c.add(joinPath("labels", key), "must be a string")
+			}
+		}
+	}
+
+	_, hasAPI := root["api"]
+	_, hasEvents := root["events"]
+	if !hasAPI && !hasEvents {
+		c.add("api", "api or events is required")
+	}
+
+	if api := c.objectField(root, "", "api", false); api != nil {
+		targetURL := c.stringField(api, "api", "targetUrl", true)
+		if targetURL != "" {
+			if parsed, err := url.Parse(targetURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				c.add("api.targetUrl", "%q is not an absolute http(s) url", targetURL)
+			}
+		}
+
+		apiType := c.stringField(api, "api", "apiType", false)
+		if spec, ok := api["spec"]; ok && !strings.EqualFold(apiType, "odata") {
+			c.embedded(spec, "api.spec", (*checker).openAPI)
+		}
+	}
+
+	if events := c.objectField(root, "", "events", false); events != nil {
+		if spec, ok := events["spec"]; ok {
+			c.embedded(spec, "events.spec", (*checker).asyncAPI)
+		} else {
+			c.add("events.spec", "is required")
+		}
+	}
+
+	return c.problems
+}
+
+//checks a spec embedded in the envelope, local references resolve within the spec
+func (c *checker) embedded(value interface{}, path string, check func(*checker, map[string]interface{}, string)) {
+	spec := c.object(value, path)
+	if spec == nil {
+		return
+	}
+
+	embedded := &checker{root: spec}
+	check(embedded, spec, path)
+	c.problems = append(c.problems, embedded.problems...)
+}
@@ -0,0 +1,213 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//Problem - something wrong in a document, the path points to the value e.g. paths./orders.get.responses
+type Problem struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (problem Problem) String() string {
+	if problem.Path == "" {
+		return problem.Message
+	}
+	return problem.Path + ": " + problem.Message
+}
+
+//collects the problems of a document, root is used to resolve local references
+type checker struct {
+	root     map[string]interface{}
+	problems []Problem
+}
+
+//Parse - decodes a json or yaml document, yaml mappings are returned as map[string]interface{} like json objects
+func Parse(document []byte) (interface{}, error) {
+	var value interface{}
+
+	if json.Valid(document) {
+		err := json.Unmarshal(document, &value)
+		return value, err
+	}
+
+	if err := yaml.Unmarshal(document, &value); err != nil {
+		return nil, err
+	}
+	return normalize(value), nil
+}
+
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := map[string]interface{}{}
+		for key, item := range value {
+			object[fmt.Sprint(key)] = normalize(item)
+		}
+		return object
+	case []interface{}:
+		for i := range value {
+			value[i] = normalize(value[i])
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+//parses the document, which must be an object
+func parseObject(document []byte) (map[string]interface{}, []Problem) {
+	if len(strings.TrimSpace(string(document))) == 0 {
+		return nil, []Problem{{Message: "the document is empty"}}
+	}
+
+	value, err := Parse(document)
+	if err != nil {
+		return nil, []Problem{{Message: "the document is neither valid json nor yaml: " + err.Error()}}
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, []Problem{{Message: "the document must be an object"}}
+	}
+	return object, nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func (c *checker) add(path string, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+//the object at path, nil and a problem if the value is something else
+func (c *checker) object(value interface{}, path string) map[string]interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		c.add(path, "must be an object")
+		return nil
+	}
+	return object
+}
+
+//the object under key, a missing key is a problem if required
+func (c *checker) objectField(parent map[string]interface{}, path string, key string, required bool) map[string]interface{} {
+	value, ok := parent[key]
+	if !ok {
+		if required {
+			c.add(joinPath(path, key), "is required")
+		}
+		return nil
+	}
+	return c.object(value, joinPath(path, key))
+}
+
+//the string under key, a missing or empty value is a problem if required
+func (c *checker) stringField(parent map[string]interface{}, path string, key string, required bool) string {
+	value, ok := parent[key]
+	if !ok {
+		if required {
+			c.add(joinPath(path, key), "is required")
+		}
+		return ""
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		c.add(joinPath(path, key), "must be a string")
+		return ""
+	}
+	if required && strings.TrimSpace(text) == "" {
+		c.add(joinPath(path, key), "must not be empty")
+	}
+	return text
+}
+
+//the info object shared by open api and async api
+func (c *checker) info(document map[string]interface{}, path string) {
+	info := c.objectField(document, path, "info", true)
+	if info == nil {
+		return
+	}
+	c.stringField(info, joinPath(path, "info"), "title", true)
+	c.stringField(info, joinPath(path, "info"), "version", true)
+}
+
+//reports local references ("#/components/...") that do not resolve
+func (c *checker) references(value interface{}, path string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			if c.resolve(ref) == nil {
+				c.add(joinPath(path, "$ref"), "%s does not exist", ref)
+			}
+		}
+		for _, key := range sortedKeys(value) {
+			c.references(value[key], joinPath(path, key))
+		}
+	case []interface{}:
+		for i, item := range value {
+			c.references(item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+//follows a local json pointer from the root of the document
+func (c *checker) resolve(ref string) interface{} {
+	var value interface{} = c.root
+
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		if unescaped, err := url.PathUnescape(token); err == nil {
+			token = unescaped
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if value, ok = object[token]; !ok {
+			return nil
+		}
+	}
+	return value
+}
+
+//the referenced object if value is a local reference, else value
+func (c *checker) dereference(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	ref, ok := object["$ref"].(string)
+	if !ok {
+		return value
+	}
+
+	if resolved := c.resolve(ref); resolved != nil {
+		return resolved
+	}
+	//unresolvable references are reported by references
+	return nil
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package validate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type validatorTest struct {
+	name     string
+	document string
+	expected []string
+}
+
+//the problems of every document as strings, nil for a valid document
+func runValidatorTests(t *testing.T, validator func([]byte) []Problem, tests []validatorTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var problems []string
+			for _, problem := range validator([]byte(test.document)) {
+				problems = append(problems, problem.String())
+			}
+			if !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("problems = %q, want %q", problems, test.expected)
+			}
+		})
+	}
+}
+
+//the sample specs registered by the app must stay valid
+func TestSampleSpecs(t *testing.T) {
+
+	tests := map[string]func([]byte) []Problem{
+		"api-graphql.yaml":   OpenAPI,
+		"event-graphql.yaml": AsyncAPI,
+		"api-rest.json":      Service,
+	}
+
+	for name, validator := range tests {
+		document, err := ioutil.ReadFile(filepath.Join("..", "..", "assets", "spec-docs", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if problems := validator(document); len(problems) != 0 {
+			t.Errorf("%s: %v", name, problems)
+		}
+	}
+}
+
+const validOpenAPI = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Orders", "version": "1.0.0"},
+  "paths": {
+    "/orders/{id}": {
+      "get": {
+        "parameters": [{"name": "id", "in": "path", "required": true}],
+        "responses": {"200": {"description": "the order", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}}}
+      }
+    }
+  },
+  "components": {"schemas": {"Order": {"type": "object"}}}
+}`
+
+func TestOpenAPI(t *testing.T) {
+
+	runValidatorTests(t, OpenAPI, []validatorTest{
+		{"valid json", validOpenAPI, nil},
+		{"valid yaml", `
+openapi: 3.0.2
+info:
+  title: Orders
+  version: "1"
+paths:
+  /orders:
+    parameters:
+      - name: limit
+        in: query
+    post:
+      responses:
+        default:
+          description: the created order
+`, nil},
+		{"empty", " \n", []string{"the document is empty"}},
+		{"no object", `["openapi"]`, []string{"the document must be an object"}},
+		{"swagger", `{"swagger": "2.0", "info": {"title": "Orders", "version": "1"}, "paths": {}}`, []string{
+			"swagger: Swagger 2.0 documents are not supported, OpenAPI 3.x is required",
+		}},
+		{"unsupported version", `{"openapi": "2.0", "info": {"title": "Orders", "version": "1"}, "paths": {}}`, []string{
+			`openapi: version "2.0" is not supported, OpenAPI 3.x is required`,
+		}},
+		{"missing info and paths", `{"openapi": "3.0.0"}`, []string{
+			"info: is required",
+			"paths: is required",
+		}},
+		{"invalid path", `{"openapi": "3.0.0", "info": {"title": "Orders", "version": "1"}, "paths": {"orders": {}}}`, []string{
+			"paths.orders: path must start with /",
+		}},
+		{"undeclared path parameter", `{"openapi": "3.0.0", "info": {"title": "Orders", "version": "1"},
+			"paths": {"/orders/{id}": {"get": {"responses": {"200": {"description": "the order"}}}}}}`, []string{
+			`paths./orders/{id}.get.parameters: path parameter "id" is not declared`,
+		}},
+		{"optional path parameter", `{"openapi": "3.0.0", "info": {"title": "Orders", "version": "1"},
+			"paths": {"/orders/{id}": {"parameters": [{"name": "id", "in": "path"}], "get": {"responses": {"200": {"description": "the order"}}}}}}`, []string{
+			"paths./orders/{id}.parameters[0].required: path parameters must be required",
+		}},
+		{"invalid responses", `{"openapi": "3.0.0", "info": {"title": "Orders", "version": "1"},
+			"paths": {"/orders": {"get": {"responses": {"ok": {"description": "the orders"}, "404": {}}}, "post": {}}}}`, []string{
+			"paths./orders.get.responses.404.description: is required",
+			`paths./orders.get.responses.ok: "ok" is not a status code`,
+			"paths./orders.post.responses: is required",
+		}},
+		{"missing reference", `{"openapi": "3.0.0", "info": {"title": "Orders", "version": "1"},
+			"paths": {"/orders": {"get": {"responses": {"200": {"$ref": "#/components/responses/Orders"}}}}}}`, []string{
+			"paths./orders.get.responses.200.$ref: #/components/responses/Orders does not exist",
+		}},
+	})
+}
+
+func TestAsyncAPI(t *testing.T) {
+
+	runValidatorTests(t, AsyncAPI, []validatorTest{
+		{"valid 1.x", `{
+  "asyncapi": "1.0.0",
+  "info": {"title": "Orders", "version": "1.0.0"},
+  "topics": {"order.created.v1": {"subscribe": {"$ref": "#/components/messages/OrderCreated"}}},
+  "components": {"messages": {"OrderCreated": {"payload": {"type": "object"}}}}
+}`, nil},
+		{"valid 2.x", `
+asyncapi: 2.0.0
+info:
+  title: Orders
+  version: "1"
+channels:
+  order.created.v1:
+    subscribe:
+      message:
+        oneOf:
+          - payload:
+              type: object
+          - payload:
+              type: object
+`, nil},
+		{"empty", "", []string{"the document is empty"}},
+		{"unsupported version", `{"asyncapi": "3.0.0", "info": {"title": "Orders", "version": "1"}}`, []string{
+			`asyncapi: version "3.0.0" is not supported, AsyncAPI 1.x or 2.x is required`,
+		}},
+		{"1.x without topics", `{"asyncapi": "1.2.0", "info": {"title": "Orders", "version": "1"}}`, []string{
+			"topics: one of topics, stream or events is required",
+		}},
+		{"1.x topic without operations", `{"asyncapi": "1.2.0", "info": {"title": "Orders", "version": "1"}, "topics": {"order.created.v1": {}}}`, []string{
+			"topics.order.created.v1: publish or subscribe is required",
+		}},
+		{"2.x without channels", `{"asyncapi": "2.0.0", "info": {"title": "Orders"}}`, []string{
+			"channels: is required",
+			"info.version: is required",
+		}},
+		{"payload no object", `{"asyncapi": "2.0.0", "info": {"title": "Orders", "version": "1"},
+			"channels": {"order.created.v1": {"subscribe": {"message": {"payload": "order"}}}}}`, []string{
+			"channels.order.created.v1.subscribe.message.payload: must be an object",
+		}},
+		{"missing message", `{"asyncapi": "1.0.0", "info": {"title": "Orders", "version": "1"},
+			"topics": {"order.created.v1": {"publish": {"$ref": "#/components/messages/OrderCreated"}}}}`, []string{
+			"topics.order.created.v1.publish.$ref: #/components/messages/OrderCreated does not exist",
+		}},
+	})
+}
+
+func TestService(t *testing.T) {
+
+	runValidatorTests(t, Service, []validatorTest{
+		{"valid", `{"provider": "SAP", "name": "orders", "labels": {"app": "orders"},
+			"api": {"targetUrl": "http://localhost:8080", "spec": ` + validOpenAPI + `},
+			"events": {"spec": {"asyncapi": "1.0.0", "info": {"title": "Orders", "version": "1"}, "topics": {"order.created.v1": {"subscribe": {}}}}}}`, nil},
+		{"odata", `{"provider": "SAP", "name": "orders", "api": {"targetUrl": "https://localhost", "apiType": "ODATA", "spec": {"edmx": true}}}`, nil},
+		{"missing fields", `{"labels": {"app": 1}}`, []string{
+			"provider: is required",
+			"name: is required",
+			"labels.app: must be a string",
+			"api: api or events is required",
+		}},
+		{"invalid api and events", `{"provider": "SAP", "name": "orders",
+			"api": {"targetUrl": "localhost:8080", "spec": {"openapi": "3.0.0", "info": {"title": "Orders", "version": "1"}}},
+			"events": {}}`, []string{
+			`api.targetUrl: "localhost:8080" is not an absolute http(s) url`,
+			"api.spec.paths: is required",
+			"events.spec: is required",
+		}},
+	})
+}